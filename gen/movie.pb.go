@@ -21,6 +21,104 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type MetadataOrder int32
+
+const (
+	MetadataOrder_METADATA_ORDER_ID        MetadataOrder = 0
+	MetadataOrder_METADATA_ORDER_YEAR_DESC MetadataOrder = 1
+	MetadataOrder_METADATA_ORDER_YEAR_ASC  MetadataOrder = 2
+)
+
+// Enum value maps for MetadataOrder.
+var (
+	MetadataOrder_name = map[int32]string{
+		0: "METADATA_ORDER_ID",
+		1: "METADATA_ORDER_YEAR_DESC",
+		2: "METADATA_ORDER_YEAR_ASC",
+	}
+	MetadataOrder_value = map[string]int32{
+		"METADATA_ORDER_ID":        0,
+		"METADATA_ORDER_YEAR_DESC": 1,
+		"METADATA_ORDER_YEAR_ASC":  2,
+	}
+)
+
+func (x MetadataOrder) Enum() *MetadataOrder {
+	p := new(MetadataOrder)
+	*p = x
+	return p
+}
+
+func (x MetadataOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MetadataOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_movie_proto_enumTypes[0].Descriptor()
+}
+
+func (MetadataOrder) Type() protoreflect.EnumType {
+	return &file_movie_proto_enumTypes[0]
+}
+
+func (x MetadataOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MetadataOrder.Descriptor instead.
+func (MetadataOrder) EnumDescriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{0}
+}
+
+type MovieSort int32
+
+const (
+	MovieSort_MOVIE_SORT_UNSPECIFIED MovieSort = 0
+	MovieSort_MOVIE_SORT_RATING      MovieSort = 1
+	MovieSort_MOVIE_SORT_YEAR        MovieSort = 2
+)
+
+// Enum value maps for MovieSort.
+var (
+	MovieSort_name = map[int32]string{
+		0: "MOVIE_SORT_UNSPECIFIED",
+		1: "MOVIE_SORT_RATING",
+		2: "MOVIE_SORT_YEAR",
+	}
+	MovieSort_value = map[string]int32{
+		"MOVIE_SORT_UNSPECIFIED": 0,
+		"MOVIE_SORT_RATING":      1,
+		"MOVIE_SORT_YEAR":        2,
+	}
+)
+
+func (x MovieSort) Enum() *MovieSort {
+	p := new(MovieSort)
+	*p = x
+	return p
+}
+
+func (x MovieSort) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MovieSort) Descriptor() protoreflect.EnumDescriptor {
+	return file_movie_proto_enumTypes[1].Descriptor()
+}
+
+func (MovieSort) Type() protoreflect.EnumType {
+	return &file_movie_proto_enumTypes[1]
+}
+
+func (x MovieSort) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MovieSort.Descriptor instead.
+func (MovieSort) EnumDescriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{1}
+}
+
 type Metadata struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -28,6 +126,7 @@ type Metadata struct {
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	Year          int32                  `protobuf:"varint,4,opt,name=year,proto3" json:"year,omitempty"`
 	Director      string                 `protobuf:"bytes,5,opt,name=director,proto3" json:"director,omitempty"`
+	Tags          []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -97,10 +196,20 @@ func (x *Metadata) GetDirector() string {
 	return ""
 }
 
+func (x *Metadata) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 type MovieDetails struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Rating        *float64               `protobuf:"fixed64,1,opt,name=rating,proto3,oneof" json:"rating,omitempty"`
-	Metadata      *Metadata              `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Rating   *float64               `protobuf:"fixed64,1,opt,name=rating,proto3,oneof" json:"rating,omitempty"`
+	Metadata *Metadata              `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// Set when the metadata service could not be reached and metadata was
+	// served from an earlier response, so it may be out of date.
+	Stale         bool `protobuf:"varint,3,opt,name=stale,proto3" json:"stale,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -149,6 +258,118 @@ func (x *MovieDetails) GetMetadata() *Metadata {
 	return nil
 }
 
+func (x *MovieDetails) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+type AggregatedRating struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Rating        float64                `protobuf:"fixed64,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregatedRating) Reset() {
+	*x = AggregatedRating{}
+	mi := &file_movie_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregatedRating) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregatedRating) ProtoMessage() {}
+
+func (x *AggregatedRating) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregatedRating.ProtoReflect.Descriptor instead.
+func (*AggregatedRating) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AggregatedRating) GetMovieId() int32 {
+	if x != nil {
+		return x.MovieId
+	}
+	return 0
+}
+
+func (x *AggregatedRating) GetRating() float64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+// Number of ratings per rating value.
+type RatingDistribution struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Counts        map[int32]int64        `protobuf:"bytes,2,rep,name=counts,proto3" json:"counts,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RatingDistribution) Reset() {
+	*x = RatingDistribution{}
+	mi := &file_movie_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RatingDistribution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatingDistribution) ProtoMessage() {}
+
+func (x *RatingDistribution) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatingDistribution.ProtoReflect.Descriptor instead.
+func (*RatingDistribution) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RatingDistribution) GetMovieId() int32 {
+	if x != nil {
+		return x.MovieId
+	}
+	return 0
+}
+
+func (x *RatingDistribution) GetCounts() map[int32]int64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
 type GetMetadataRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -158,7 +379,7 @@ type GetMetadataRequest struct {
 
 func (x *GetMetadataRequest) Reset() {
 	*x = GetMetadataRequest{}
-	mi := &file_movie_proto_msgTypes[2]
+	mi := &file_movie_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -170,7 +391,7 @@ func (x *GetMetadataRequest) String() string {
 func (*GetMetadataRequest) ProtoMessage() {}
 
 func (x *GetMetadataRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[2]
+	mi := &file_movie_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -183,7 +404,7 @@ func (x *GetMetadataRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMetadataRequest.ProtoReflect.Descriptor instead.
 func (*GetMetadataRequest) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{2}
+	return file_movie_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetMetadataRequest) GetId() int32 {
@@ -202,7 +423,7 @@ type GetMetadataResponse struct {
 
 func (x *GetMetadataResponse) Reset() {
 	*x = GetMetadataResponse{}
-	mi := &file_movie_proto_msgTypes[3]
+	mi := &file_movie_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -214,7 +435,7 @@ func (x *GetMetadataResponse) String() string {
 func (*GetMetadataResponse) ProtoMessage() {}
 
 func (x *GetMetadataResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[3]
+	mi := &file_movie_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -227,7 +448,7 @@ func (x *GetMetadataResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMetadataResponse.ProtoReflect.Descriptor instead.
 func (*GetMetadataResponse) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{3}
+	return file_movie_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetMetadataResponse) GetMetadata() *Metadata {
@@ -243,13 +464,14 @@ type PutMetadataRequest struct {
 	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
 	Year          int32                  `protobuf:"varint,3,opt,name=year,proto3" json:"year,omitempty"`
 	Director      string                 `protobuf:"bytes,4,opt,name=director,proto3" json:"director,omitempty"`
+	Tags          []string               `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PutMetadataRequest) Reset() {
 	*x = PutMetadataRequest{}
-	mi := &file_movie_proto_msgTypes[4]
+	mi := &file_movie_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -261,7 +483,7 @@ func (x *PutMetadataRequest) String() string {
 func (*PutMetadataRequest) ProtoMessage() {}
 
 func (x *PutMetadataRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[4]
+	mi := &file_movie_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -274,7 +496,7 @@ func (x *PutMetadataRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutMetadataRequest.ProtoReflect.Descriptor instead.
 func (*PutMetadataRequest) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{4}
+	return file_movie_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PutMetadataRequest) GetTitle() string {
@@ -305,6 +527,13 @@ func (x *PutMetadataRequest) GetDirector() string {
 	return ""
 }
 
+func (x *PutMetadataRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 type PutMetadataResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -313,7 +542,7 @@ type PutMetadataResponse struct {
 
 func (x *PutMetadataResponse) Reset() {
 	*x = PutMetadataResponse{}
-	mi := &file_movie_proto_msgTypes[5]
+	mi := &file_movie_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -325,7 +554,7 @@ func (x *PutMetadataResponse) String() string {
 func (*PutMetadataResponse) ProtoMessage() {}
 
 func (x *PutMetadataResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[5]
+	mi := &file_movie_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -338,31 +567,32 @@ func (x *PutMetadataResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutMetadataResponse.ProtoReflect.Descriptor instead.
 func (*PutMetadataResponse) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{5}
+	return file_movie_proto_rawDescGZIP(), []int{7}
 }
 
-type GetAggregatedRatingRequest struct {
+// Ids that do not exist are omitted from the response.
+type BatchGetMetadataRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Ids           []int32                `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetAggregatedRatingRequest) Reset() {
-	*x = GetAggregatedRatingRequest{}
-	mi := &file_movie_proto_msgTypes[6]
+func (x *BatchGetMetadataRequest) Reset() {
+	*x = BatchGetMetadataRequest{}
+	mi := &file_movie_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetAggregatedRatingRequest) String() string {
+func (x *BatchGetMetadataRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetAggregatedRatingRequest) ProtoMessage() {}
+func (*BatchGetMetadataRequest) ProtoMessage() {}
 
-func (x *GetAggregatedRatingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[6]
+func (x *BatchGetMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -373,40 +603,40 @@ func (x *GetAggregatedRatingRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetAggregatedRatingRequest.ProtoReflect.Descriptor instead.
-func (*GetAggregatedRatingRequest) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use BatchGetMetadataRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *GetAggregatedRatingRequest) GetMovieId() int32 {
+func (x *BatchGetMetadataRequest) GetIds() []int32 {
 	if x != nil {
-		return x.MovieId
+		return x.Ids
 	}
-	return 0
+	return nil
 }
 
-type GetAggregatedRatingResponse struct {
+type BatchGetMetadataResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Rating        float64                `protobuf:"fixed64,1,opt,name=rating,proto3" json:"rating,omitempty"`
+	Metadata      []*Metadata            `protobuf:"bytes,1,rep,name=metadata,proto3" json:"metadata,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetAggregatedRatingResponse) Reset() {
-	*x = GetAggregatedRatingResponse{}
-	mi := &file_movie_proto_msgTypes[7]
+func (x *BatchGetMetadataResponse) Reset() {
+	*x = BatchGetMetadataResponse{}
+	mi := &file_movie_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetAggregatedRatingResponse) String() string {
+func (x *BatchGetMetadataResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetAggregatedRatingResponse) ProtoMessage() {}
+func (*BatchGetMetadataResponse) ProtoMessage() {}
 
-func (x *GetAggregatedRatingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[7]
+func (x *BatchGetMetadataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -417,41 +647,44 @@ func (x *GetAggregatedRatingResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetAggregatedRatingResponse.ProtoReflect.Descriptor instead.
-func (*GetAggregatedRatingResponse) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use BatchGetMetadataResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetMetadataResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *GetAggregatedRatingResponse) GetRating() float64 {
+func (x *BatchGetMetadataResponse) GetMetadata() []*Metadata {
 	if x != nil {
-		return x.Rating
+		return x.Metadata
 	}
-	return 0
+	return nil
 }
 
-type PutRatingRequest struct {
+// When directors is set only movies by those directors are listed.
+type ListMetadataRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
-	Rating        int32                  `protobuf:"varint,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Order         MetadataOrder          `protobuf:"varint,3,opt,name=order,proto3,enum=MetadataOrder" json:"order,omitempty"`
+	Directors     []string               `protobuf:"bytes,4,rep,name=directors,proto3" json:"directors,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PutRatingRequest) Reset() {
-	*x = PutRatingRequest{}
-	mi := &file_movie_proto_msgTypes[8]
+func (x *ListMetadataRequest) Reset() {
+	*x = ListMetadataRequest{}
+	mi := &file_movie_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PutRatingRequest) String() string {
+func (x *ListMetadataRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PutRatingRequest) ProtoMessage() {}
+func (*ListMetadataRequest) ProtoMessage() {}
 
-func (x *PutRatingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[8]
+func (x *ListMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -462,46 +695,61 @@ func (x *PutRatingRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PutRatingRequest.ProtoReflect.Descriptor instead.
-func (*PutRatingRequest) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use ListMetadataRequest.ProtoReflect.Descriptor instead.
+func (*ListMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *PutRatingRequest) GetMovieId() int32 {
+func (x *ListMetadataRequest) GetLimit() int32 {
 	if x != nil {
-		return x.MovieId
+		return x.Limit
 	}
 	return 0
 }
 
-func (x *PutRatingRequest) GetRating() int32 {
+func (x *ListMetadataRequest) GetOffset() int32 {
 	if x != nil {
-		return x.Rating
+		return x.Offset
 	}
 	return 0
 }
 
-type PutRatingResponse struct {
+func (x *ListMetadataRequest) GetOrder() MetadataOrder {
+	if x != nil {
+		return x.Order
+	}
+	return MetadataOrder_METADATA_ORDER_ID
+}
+
+func (x *ListMetadataRequest) GetDirectors() []string {
+	if x != nil {
+		return x.Directors
+	}
+	return nil
+}
+
+type ListMetadataResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      []*Metadata            `protobuf:"bytes,1,rep,name=metadata,proto3" json:"metadata,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PutRatingResponse) Reset() {
-	*x = PutRatingResponse{}
-	mi := &file_movie_proto_msgTypes[9]
+func (x *ListMetadataResponse) Reset() {
+	*x = ListMetadataResponse{}
+	mi := &file_movie_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PutRatingResponse) String() string {
+func (x *ListMetadataResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PutRatingResponse) ProtoMessage() {}
+func (*ListMetadataResponse) ProtoMessage() {}
 
-func (x *PutRatingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[9]
+func (x *ListMetadataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -512,33 +760,579 @@ func (x *PutRatingResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PutRatingResponse.ProtoReflect.Descriptor instead.
-func (*PutRatingResponse) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use ListMetadataResponse.ProtoReflect.Descriptor instead.
+func (*ListMetadataResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{11}
 }
 
-type GetMovieDetailsRequest struct {
+func (x *ListMetadataResponse) GetMetadata() []*Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GetAggregatedRatingRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMovieDetailsRequest) Reset() {
-	*x = GetMovieDetailsRequest{}
-	mi := &file_movie_proto_msgTypes[10]
+func (x *GetAggregatedRatingRequest) Reset() {
+	*x = GetAggregatedRatingRequest{}
+	mi := &file_movie_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAggregatedRatingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAggregatedRatingRequest) ProtoMessage() {}
+
+func (x *GetAggregatedRatingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAggregatedRatingRequest.ProtoReflect.Descriptor instead.
+func (*GetAggregatedRatingRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetAggregatedRatingRequest) GetMovieId() int32 {
+	if x != nil {
+		return x.MovieId
+	}
+	return 0
+}
+
+type GetAggregatedRatingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rating        float64                `protobuf:"fixed64,1,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAggregatedRatingResponse) Reset() {
+	*x = GetAggregatedRatingResponse{}
+	mi := &file_movie_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAggregatedRatingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAggregatedRatingResponse) ProtoMessage() {}
+
+func (x *GetAggregatedRatingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAggregatedRatingResponse.ProtoReflect.Descriptor instead.
+func (*GetAggregatedRatingResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetAggregatedRatingResponse) GetRating() float64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+type PutRatingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Rating        int32                  `protobuf:"varint,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutRatingRequest) Reset() {
+	*x = PutRatingRequest{}
+	mi := &file_movie_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutRatingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutRatingRequest) ProtoMessage() {}
+
+func (x *PutRatingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutRatingRequest.ProtoReflect.Descriptor instead.
+func (*PutRatingRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PutRatingRequest) GetMovieId() int32 {
+	if x != nil {
+		return x.MovieId
+	}
+	return 0
+}
+
+func (x *PutRatingRequest) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+type PutRatingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutRatingResponse) Reset() {
+	*x = PutRatingResponse{}
+	mi := &file_movie_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutRatingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutRatingResponse) ProtoMessage() {}
+
+func (x *PutRatingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutRatingResponse.ProtoReflect.Descriptor instead.
+func (*PutRatingResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{15}
+}
+
+// Movies without ratings are omitted from the response.
+type BatchGetAggregatedRatingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieIds      []int32                `protobuf:"varint,1,rep,packed,name=movie_ids,json=movieIds,proto3" json:"movie_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetAggregatedRatingsRequest) Reset() {
+	*x = BatchGetAggregatedRatingsRequest{}
+	mi := &file_movie_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetAggregatedRatingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetAggregatedRatingsRequest) ProtoMessage() {}
+
+func (x *BatchGetAggregatedRatingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetAggregatedRatingsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetAggregatedRatingsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BatchGetAggregatedRatingsRequest) GetMovieIds() []int32 {
+	if x != nil {
+		return x.MovieIds
+	}
+	return nil
+}
+
+type BatchGetAggregatedRatingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ratings       []*AggregatedRating    `protobuf:"bytes,1,rep,name=ratings,proto3" json:"ratings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetAggregatedRatingsResponse) Reset() {
+	*x = BatchGetAggregatedRatingsResponse{}
+	mi := &file_movie_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetAggregatedRatingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetAggregatedRatingsResponse) ProtoMessage() {}
+
+func (x *BatchGetAggregatedRatingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetAggregatedRatingsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetAggregatedRatingsResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BatchGetAggregatedRatingsResponse) GetRatings() []*AggregatedRating {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+// Lists rated movies ordered by aggregated rating, highest first.
+type ListAggregatedRatingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAggregatedRatingsRequest) Reset() {
+	*x = ListAggregatedRatingsRequest{}
+	mi := &file_movie_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAggregatedRatingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAggregatedRatingsRequest) ProtoMessage() {}
+
+func (x *ListAggregatedRatingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAggregatedRatingsRequest.ProtoReflect.Descriptor instead.
+func (*ListAggregatedRatingsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListAggregatedRatingsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAggregatedRatingsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListAggregatedRatingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ratings       []*AggregatedRating    `protobuf:"bytes,1,rep,name=ratings,proto3" json:"ratings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAggregatedRatingsResponse) Reset() {
+	*x = ListAggregatedRatingsResponse{}
+	mi := &file_movie_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAggregatedRatingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAggregatedRatingsResponse) ProtoMessage() {}
+
+func (x *ListAggregatedRatingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAggregatedRatingsResponse.ProtoReflect.Descriptor instead.
+func (*ListAggregatedRatingsResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListAggregatedRatingsResponse) GetRatings() []*AggregatedRating {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+// Movies without ratings are omitted from the response.
+type BatchGetRatingDistributionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieIds      []int32                `protobuf:"varint,1,rep,packed,name=movie_ids,json=movieIds,proto3" json:"movie_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetRatingDistributionsRequest) Reset() {
+	*x = BatchGetRatingDistributionsRequest{}
+	mi := &file_movie_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRatingDistributionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRatingDistributionsRequest) ProtoMessage() {}
+
+func (x *BatchGetRatingDistributionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRatingDistributionsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetRatingDistributionsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BatchGetRatingDistributionsRequest) GetMovieIds() []int32 {
+	if x != nil {
+		return x.MovieIds
+	}
+	return nil
+}
+
+type BatchGetRatingDistributionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distributions []*RatingDistribution  `protobuf:"bytes,1,rep,name=distributions,proto3" json:"distributions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetRatingDistributionsResponse) Reset() {
+	*x = BatchGetRatingDistributionsResponse{}
+	mi := &file_movie_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRatingDistributionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRatingDistributionsResponse) ProtoMessage() {}
+
+func (x *BatchGetRatingDistributionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRatingDistributionsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetRatingDistributionsResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BatchGetRatingDistributionsResponse) GetDistributions() []*RatingDistribution {
+	if x != nil {
+		return x.Distributions
+	}
+	return nil
+}
+
+type GetMovieDetailsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMovieDetailsRequest) Reset() {
+	*x = GetMovieDetailsRequest{}
+	mi := &file_movie_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMovieDetailsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMovieDetailsRequest) ProtoMessage() {}
+
+func (x *GetMovieDetailsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMovieDetailsRequest.ProtoReflect.Descriptor instead.
+func (*GetMovieDetailsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetMovieDetailsRequest) GetMovieId() int32 {
+	if x != nil {
+		return x.MovieId
+	}
+	return 0
+}
+
+type GetMovieDetailsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieDetails  *MovieDetails          `protobuf:"bytes,1,opt,name=movie_details,json=movieDetails,proto3" json:"movie_details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMovieDetailsResponse) Reset() {
+	*x = GetMovieDetailsResponse{}
+	mi := &file_movie_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMovieDetailsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMovieDetailsResponse) ProtoMessage() {}
+
+func (x *GetMovieDetailsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMovieDetailsResponse.ProtoReflect.Descriptor instead.
+func (*GetMovieDetailsResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetMovieDetailsResponse) GetMovieDetails() *MovieDetails {
+	if x != nil {
+		return x.MovieDetails
+	}
+	return nil
+}
+
+type BatchGetMovieDetailsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieIds      []int32                `protobuf:"varint,1,rep,packed,name=movie_ids,json=movieIds,proto3" json:"movie_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetMovieDetailsRequest) Reset() {
+	*x = BatchGetMovieDetailsRequest{}
+	mi := &file_movie_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMovieDetailsRequest) String() string {
+func (x *BatchGetMovieDetailsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMovieDetailsRequest) ProtoMessage() {}
+func (*BatchGetMovieDetailsRequest) ProtoMessage() {}
 
-func (x *GetMovieDetailsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[10]
+func (x *BatchGetMovieDetailsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -549,40 +1343,313 @@ func (x *GetMovieDetailsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMovieDetailsRequest.ProtoReflect.Descriptor instead.
-func (*GetMovieDetailsRequest) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use BatchGetMovieDetailsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetMovieDetailsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *GetMovieDetailsRequest) GetMovieId() int32 {
+func (x *BatchGetMovieDetailsRequest) GetMovieIds() []int32 {
+	if x != nil {
+		return x.MovieIds
+	}
+	return nil
+}
+
+// One result per requested id, in request order. movie_details is unset
+// when not_found is true.
+type MovieDetailsResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	MovieDetails  *MovieDetails          `protobuf:"bytes,2,opt,name=movie_details,json=movieDetails,proto3" json:"movie_details,omitempty"`
+	NotFound      bool                   `protobuf:"varint,3,opt,name=not_found,json=notFound,proto3" json:"not_found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MovieDetailsResult) Reset() {
+	*x = MovieDetailsResult{}
+	mi := &file_movie_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MovieDetailsResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MovieDetailsResult) ProtoMessage() {}
+
+func (x *MovieDetailsResult) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MovieDetailsResult.ProtoReflect.Descriptor instead.
+func (*MovieDetailsResult) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *MovieDetailsResult) GetMovieId() int32 {
 	if x != nil {
 		return x.MovieId
 	}
 	return 0
 }
 
-type GetMovieDetailsResponse struct {
+func (x *MovieDetailsResult) GetMovieDetails() *MovieDetails {
+	if x != nil {
+		return x.MovieDetails
+	}
+	return nil
+}
+
+func (x *MovieDetailsResult) GetNotFound() bool {
+	if x != nil {
+		return x.NotFound
+	}
+	return false
+}
+
+type BatchGetMovieDetailsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*MovieDetailsResult  `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetMovieDetailsResponse) Reset() {
+	*x = BatchGetMovieDetailsResponse{}
+	mi := &file_movie_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetMovieDetailsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetMovieDetailsResponse) ProtoMessage() {}
+
+func (x *BatchGetMovieDetailsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetMovieDetailsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetMovieDetailsResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *BatchGetMovieDetailsResponse) GetResults() []*MovieDetailsResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// Pages are numbered from 1. Sorting by rating only lists rated movies.
+type ListMoviesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Sort          MovieSort              `protobuf:"varint,3,opt,name=sort,proto3,enum=MovieSort" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMoviesRequest) Reset() {
+	*x = ListMoviesRequest{}
+	mi := &file_movie_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMoviesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMoviesRequest) ProtoMessage() {}
+
+func (x *ListMoviesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMoviesRequest.ProtoReflect.Descriptor instead.
+func (*ListMoviesRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListMoviesRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListMoviesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListMoviesRequest) GetSort() MovieSort {
+	if x != nil {
+		return x.Sort
+	}
+	return MovieSort_MOVIE_SORT_UNSPECIFIED
+}
+
+type ListMoviesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movies        []*MovieDetails        `protobuf:"bytes,1,rep,name=movies,proto3" json:"movies,omitempty"`
+	NextPage      int32                  `protobuf:"varint,2,opt,name=next_page,json=nextPage,proto3" json:"next_page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMoviesResponse) Reset() {
+	*x = ListMoviesResponse{}
+	mi := &file_movie_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMoviesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMoviesResponse) ProtoMessage() {}
+
+func (x *ListMoviesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMoviesResponse.ProtoReflect.Descriptor instead.
+func (*ListMoviesResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListMoviesResponse) GetMovies() []*MovieDetails {
+	if x != nil {
+		return x.Movies
+	}
+	return nil
+}
+
+func (x *ListMoviesResponse) GetNextPage() int32 {
+	if x != nil {
+		return x.NextPage
+	}
+	return 0
+}
+
+type GetSimilarMoviesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       int32                  `protobuf:"varint,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSimilarMoviesRequest) Reset() {
+	*x = GetSimilarMoviesRequest{}
+	mi := &file_movie_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSimilarMoviesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSimilarMoviesRequest) ProtoMessage() {}
+
+func (x *GetSimilarMoviesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSimilarMoviesRequest.ProtoReflect.Descriptor instead.
+func (*GetSimilarMoviesRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetSimilarMoviesRequest) GetMovieId() int32 {
+	if x != nil {
+		return x.MovieId
+	}
+	return 0
+}
+
+func (x *GetSimilarMoviesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Score is in [0, 1], higher means more similar.
+type SimilarMovie struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	MovieDetails  *MovieDetails          `protobuf:"bytes,1,opt,name=movie_details,json=movieDetails,proto3" json:"movie_details,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMovieDetailsResponse) Reset() {
-	*x = GetMovieDetailsResponse{}
-	mi := &file_movie_proto_msgTypes[11]
+func (x *SimilarMovie) Reset() {
+	*x = SimilarMovie{}
+	mi := &file_movie_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMovieDetailsResponse) String() string {
+func (x *SimilarMovie) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMovieDetailsResponse) ProtoMessage() {}
+func (*SimilarMovie) ProtoMessage() {}
 
-func (x *GetMovieDetailsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_movie_proto_msgTypes[11]
+func (x *SimilarMovie) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -593,43 +1660,117 @@ func (x *GetMovieDetailsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMovieDetailsResponse.ProtoReflect.Descriptor instead.
-func (*GetMovieDetailsResponse) Descriptor() ([]byte, []int) {
-	return file_movie_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use SimilarMovie.ProtoReflect.Descriptor instead.
+func (*SimilarMovie) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *GetMovieDetailsResponse) GetMovieDetails() *MovieDetails {
+func (x *SimilarMovie) GetMovieDetails() *MovieDetails {
 	if x != nil {
 		return x.MovieDetails
 	}
 	return nil
 }
 
+func (x *SimilarMovie) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type GetSimilarMoviesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movies        []*SimilarMovie        `protobuf:"bytes,1,rep,name=movies,proto3" json:"movies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSimilarMoviesResponse) Reset() {
+	*x = GetSimilarMoviesResponse{}
+	mi := &file_movie_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSimilarMoviesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSimilarMoviesResponse) ProtoMessage() {}
+
+func (x *GetSimilarMoviesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSimilarMoviesResponse.ProtoReflect.Descriptor instead.
+func (*GetSimilarMoviesResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetSimilarMoviesResponse) GetMovies() []*SimilarMovie {
+	if x != nil {
+		return x.Movies
+	}
+	return nil
+}
+
 var File_movie_proto protoreflect.FileDescriptor
 
 const file_movie_proto_rawDesc = "" +
 	"\n" +
-	"\vmovie.proto\"\x82\x01\n" +
+	"\vmovie.proto\"\x96\x01\n" +
 	"\bMetadata\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
 	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x12\n" +
 	"\x04year\x18\x04 \x01(\x05R\x04year\x12\x1a\n" +
-	"\bdirector\x18\x05 \x01(\tR\bdirector\"]\n" +
+	"\bdirector\x18\x05 \x01(\tR\bdirector\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\"s\n" +
 	"\fMovieDetails\x12\x1b\n" +
 	"\x06rating\x18\x01 \x01(\x01H\x00R\x06rating\x88\x01\x01\x12%\n" +
-	"\bmetadata\x18\x02 \x01(\v2\t.MetadataR\bmetadataB\t\n" +
-	"\a_rating\"$\n" +
+	"\bmetadata\x18\x02 \x01(\v2\t.MetadataR\bmetadata\x12\x14\n" +
+	"\x05stale\x18\x03 \x01(\bR\x05staleB\t\n" +
+	"\a_rating\"E\n" +
+	"\x10AggregatedRating\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\x05R\amovieId\x12\x16\n" +
+	"\x06rating\x18\x02 \x01(\x01R\x06rating\"\xa3\x01\n" +
+	"\x12RatingDistribution\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\x05R\amovieId\x127\n" +
+	"\x06counts\x18\x02 \x03(\v2\x1f.RatingDistribution.CountsEntryR\x06counts\x1a9\n" +
+	"\vCountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x05R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"$\n" +
 	"\x12GetMetadataRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\"<\n" +
 	"\x13GetMetadataResponse\x12%\n" +
-	"\bmetadata\x18\x01 \x01(\v2\t.MetadataR\bmetadata\"|\n" +
+	"\bmetadata\x18\x01 \x01(\v2\t.MetadataR\bmetadata\"\x90\x01\n" +
 	"\x12PutMetadataRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12 \n" +
 	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x12\n" +
 	"\x04year\x18\x03 \x01(\x05R\x04year\x12\x1a\n" +
-	"\bdirector\x18\x04 \x01(\tR\bdirector\"\x15\n" +
-	"\x13PutMetadataResponse\"7\n" +
+	"\bdirector\x18\x04 \x01(\tR\bdirector\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\"\x15\n" +
+	"\x13PutMetadataResponse\"+\n" +
+	"\x17BatchGetMetadataRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x05R\x03ids\"A\n" +
+	"\x18BatchGetMetadataResponse\x12%\n" +
+	"\bmetadata\x18\x01 \x03(\v2\t.MetadataR\bmetadata\"\x87\x01\n" +
+	"\x13ListMetadataRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12$\n" +
+	"\x05order\x18\x03 \x01(\x0e2\x0e.MetadataOrderR\x05order\x12\x1c\n" +
+	"\tdirectors\x18\x04 \x03(\tR\tdirectors\"=\n" +
+	"\x14ListMetadataResponse\x12%\n" +
+	"\bmetadata\x18\x01 \x03(\v2\t.MetadataR\bmetadata\"7\n" +
 	"\x1aGetAggregatedRatingRequest\x12\x19\n" +
 	"\bmovie_id\x18\x01 \x01(\x05R\amovieId\"5\n" +
 	"\x1bGetAggregatedRatingResponse\x12\x16\n" +
@@ -637,19 +1778,74 @@ const file_movie_proto_rawDesc = "" +
 	"\x10PutRatingRequest\x12\x19\n" +
 	"\bmovie_id\x18\x01 \x01(\x05R\amovieId\x12\x16\n" +
 	"\x06rating\x18\x02 \x01(\x05R\x06rating\"\x13\n" +
-	"\x11PutRatingResponse\"3\n" +
+	"\x11PutRatingResponse\"?\n" +
+	" BatchGetAggregatedRatingsRequest\x12\x1b\n" +
+	"\tmovie_ids\x18\x01 \x03(\x05R\bmovieIds\"P\n" +
+	"!BatchGetAggregatedRatingsResponse\x12+\n" +
+	"\aratings\x18\x01 \x03(\v2\x11.AggregatedRatingR\aratings\"L\n" +
+	"\x1cListAggregatedRatingsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"L\n" +
+	"\x1dListAggregatedRatingsResponse\x12+\n" +
+	"\aratings\x18\x01 \x03(\v2\x11.AggregatedRatingR\aratings\"A\n" +
+	"\"BatchGetRatingDistributionsRequest\x12\x1b\n" +
+	"\tmovie_ids\x18\x01 \x03(\x05R\bmovieIds\"`\n" +
+	"#BatchGetRatingDistributionsResponse\x129\n" +
+	"\rdistributions\x18\x01 \x03(\v2\x13.RatingDistributionR\rdistributions\"3\n" +
 	"\x16GetMovieDetailsRequest\x12\x19\n" +
 	"\bmovie_id\x18\x01 \x01(\x05R\amovieId\"M\n" +
 	"\x17GetMovieDetailsResponse\x122\n" +
-	"\rmovie_details\x18\x01 \x01(\v2\r.MovieDetailsR\fmovieDetails2\x85\x01\n" +
+	"\rmovie_details\x18\x01 \x01(\v2\r.MovieDetailsR\fmovieDetails\":\n" +
+	"\x1bBatchGetMovieDetailsRequest\x12\x1b\n" +
+	"\tmovie_ids\x18\x01 \x03(\x05R\bmovieIds\"\x80\x01\n" +
+	"\x12MovieDetailsResult\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\x05R\amovieId\x122\n" +
+	"\rmovie_details\x18\x02 \x01(\v2\r.MovieDetailsR\fmovieDetails\x12\x1b\n" +
+	"\tnot_found\x18\x03 \x01(\bR\bnotFound\"M\n" +
+	"\x1cBatchGetMovieDetailsResponse\x12-\n" +
+	"\aresults\x18\x01 \x03(\v2\x13.MovieDetailsResultR\aresults\"d\n" +
+	"\x11ListMoviesRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1e\n" +
+	"\x04sort\x18\x03 \x01(\x0e2\n" +
+	".MovieSortR\x04sort\"X\n" +
+	"\x12ListMoviesResponse\x12%\n" +
+	"\x06movies\x18\x01 \x03(\v2\r.MovieDetailsR\x06movies\x12\x1b\n" +
+	"\tnext_page\x18\x02 \x01(\x05R\bnextPage\"J\n" +
+	"\x17GetSimilarMoviesRequest\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\x05R\amovieId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"X\n" +
+	"\fSimilarMovie\x122\n" +
+	"\rmovie_details\x18\x01 \x01(\v2\r.MovieDetailsR\fmovieDetails\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\"A\n" +
+	"\x18GetSimilarMoviesResponse\x12%\n" +
+	"\x06movies\x18\x01 \x03(\v2\r.SimilarMovieR\x06movies*a\n" +
+	"\rMetadataOrder\x12\x15\n" +
+	"\x11METADATA_ORDER_ID\x10\x00\x12\x1c\n" +
+	"\x18METADATA_ORDER_YEAR_DESC\x10\x01\x12\x1b\n" +
+	"\x17METADATA_ORDER_YEAR_ASC\x10\x02*S\n" +
+	"\tMovieSort\x12\x1a\n" +
+	"\x16MOVIE_SORT_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11MOVIE_SORT_RATING\x10\x01\x12\x13\n" +
+	"\x0fMOVIE_SORT_YEAR\x10\x022\x8b\x02\n" +
 	"\x0fMetadataService\x128\n" +
 	"\vGetMetadata\x12\x13.GetMetadataRequest\x1a\x14.GetMetadataResponse\x128\n" +
-	"\vPutMetadata\x12\x13.PutMetadataRequest\x1a\x14.PutMetadataResponse2\x95\x01\n" +
+	"\vPutMetadata\x12\x13.PutMetadataRequest\x1a\x14.PutMetadataResponse\x12G\n" +
+	"\x10BatchGetMetadata\x12\x18.BatchGetMetadataRequest\x1a\x19.BatchGetMetadataResponse\x12;\n" +
+	"\fListMetadata\x12\x14.ListMetadataRequest\x1a\x15.ListMetadataResponse2\xbb\x03\n" +
 	"\rRatingService\x12P\n" +
 	"\x13GetAggregatedRating\x12\x1b.GetAggregatedRatingRequest\x1a\x1c.GetAggregatedRatingResponse\x122\n" +
-	"\tPutRating\x12\x11.PutRatingRequest\x1a\x12.PutRatingResponse2T\n" +
+	"\tPutRating\x12\x11.PutRatingRequest\x1a\x12.PutRatingResponse\x12b\n" +
+	"\x19BatchGetAggregatedRatings\x12!.BatchGetAggregatedRatingsRequest\x1a\".BatchGetAggregatedRatingsResponse\x12V\n" +
+	"\x15ListAggregatedRatings\x12\x1d.ListAggregatedRatingsRequest\x1a\x1e.ListAggregatedRatingsResponse\x12h\n" +
+	"\x1bBatchGetRatingDistributions\x12#.BatchGetRatingDistributionsRequest\x1a$.BatchGetRatingDistributionsResponse2\xdd\x02\n" +
 	"\fMovieService\x12D\n" +
-	"\x0fGetMovieDetails\x12\x17.GetMovieDetailsRequest\x1a\x18.GetMovieDetailsResponseB\aZ\x05./genb\x06proto3"
+	"\x0fGetMovieDetails\x12\x17.GetMovieDetailsRequest\x1a\x18.GetMovieDetailsResponse\x12S\n" +
+	"\x14BatchGetMovieDetails\x12\x1c.BatchGetMovieDetailsRequest\x1a\x1d.BatchGetMovieDetailsResponse\x125\n" +
+	"\n" +
+	"ListMovies\x12\x12.ListMoviesRequest\x1a\x13.ListMoviesResponse\x122\n" +
+	"\tPutRating\x12\x11.PutRatingRequest\x1a\x12.PutRatingResponse\x12G\n" +
+	"\x10GetSimilarMovies\x12\x18.GetSimilarMoviesRequest\x1a\x19.GetSimilarMoviesResponseB\aZ\x05./genb\x06proto3"
 
 var (
 	file_movie_proto_rawDescOnce sync.Once
@@ -663,40 +1859,95 @@ func file_movie_proto_rawDescGZIP() []byte {
 	return file_movie_proto_rawDescData
 }
 
-var file_movie_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_movie_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_movie_proto_msgTypes = make([]protoimpl.MessageInfo, 33)
 var file_movie_proto_goTypes = []any{
-	(*Metadata)(nil),                    // 0: Metadata
-	(*MovieDetails)(nil),                // 1: MovieDetails
-	(*GetMetadataRequest)(nil),          // 2: GetMetadataRequest
-	(*GetMetadataResponse)(nil),         // 3: GetMetadataResponse
-	(*PutMetadataRequest)(nil),          // 4: PutMetadataRequest
-	(*PutMetadataResponse)(nil),         // 5: PutMetadataResponse
-	(*GetAggregatedRatingRequest)(nil),  // 6: GetAggregatedRatingRequest
-	(*GetAggregatedRatingResponse)(nil), // 7: GetAggregatedRatingResponse
-	(*PutRatingRequest)(nil),            // 8: PutRatingRequest
-	(*PutRatingResponse)(nil),           // 9: PutRatingResponse
-	(*GetMovieDetailsRequest)(nil),      // 10: GetMovieDetailsRequest
-	(*GetMovieDetailsResponse)(nil),     // 11: GetMovieDetailsResponse
+	(MetadataOrder)(0),                          // 0: MetadataOrder
+	(MovieSort)(0),                              // 1: MovieSort
+	(*Metadata)(nil),                            // 2: Metadata
+	(*MovieDetails)(nil),                        // 3: MovieDetails
+	(*AggregatedRating)(nil),                    // 4: AggregatedRating
+	(*RatingDistribution)(nil),                  // 5: RatingDistribution
+	(*GetMetadataRequest)(nil),                  // 6: GetMetadataRequest
+	(*GetMetadataResponse)(nil),                 // 7: GetMetadataResponse
+	(*PutMetadataRequest)(nil),                  // 8: PutMetadataRequest
+	(*PutMetadataResponse)(nil),                 // 9: PutMetadataResponse
+	(*BatchGetMetadataRequest)(nil),             // 10: BatchGetMetadataRequest
+	(*BatchGetMetadataResponse)(nil),            // 11: BatchGetMetadataResponse
+	(*ListMetadataRequest)(nil),                 // 12: ListMetadataRequest
+	(*ListMetadataResponse)(nil),                // 13: ListMetadataResponse
+	(*GetAggregatedRatingRequest)(nil),          // 14: GetAggregatedRatingRequest
+	(*GetAggregatedRatingResponse)(nil),         // 15: GetAggregatedRatingResponse
+	(*PutRatingRequest)(nil),                    // 16: PutRatingRequest
+	(*PutRatingResponse)(nil),                   // 17: PutRatingResponse
+	(*BatchGetAggregatedRatingsRequest)(nil),    // 18: BatchGetAggregatedRatingsRequest
+	(*BatchGetAggregatedRatingsResponse)(nil),   // 19: BatchGetAggregatedRatingsResponse
+	(*ListAggregatedRatingsRequest)(nil),        // 20: ListAggregatedRatingsRequest
+	(*ListAggregatedRatingsResponse)(nil),       // 21: ListAggregatedRatingsResponse
+	(*BatchGetRatingDistributionsRequest)(nil),  // 22: BatchGetRatingDistributionsRequest
+	(*BatchGetRatingDistributionsResponse)(nil), // 23: BatchGetRatingDistributionsResponse
+	(*GetMovieDetailsRequest)(nil),              // 24: GetMovieDetailsRequest
+	(*GetMovieDetailsResponse)(nil),             // 25: GetMovieDetailsResponse
+	(*BatchGetMovieDetailsRequest)(nil),         // 26: BatchGetMovieDetailsRequest
+	(*MovieDetailsResult)(nil),                  // 27: MovieDetailsResult
+	(*BatchGetMovieDetailsResponse)(nil),        // 28: BatchGetMovieDetailsResponse
+	(*ListMoviesRequest)(nil),                   // 29: ListMoviesRequest
+	(*ListMoviesResponse)(nil),                  // 30: ListMoviesResponse
+	(*GetSimilarMoviesRequest)(nil),             // 31: GetSimilarMoviesRequest
+	(*SimilarMovie)(nil),                        // 32: SimilarMovie
+	(*GetSimilarMoviesResponse)(nil),            // 33: GetSimilarMoviesResponse
+	nil,                                         // 34: RatingDistribution.CountsEntry
 }
 var file_movie_proto_depIdxs = []int32{
-	0,  // 0: MovieDetails.metadata:type_name -> Metadata
-	0,  // 1: GetMetadataResponse.metadata:type_name -> Metadata
-	1,  // 2: GetMovieDetailsResponse.movie_details:type_name -> MovieDetails
-	2,  // 3: MetadataService.GetMetadata:input_type -> GetMetadataRequest
-	4,  // 4: MetadataService.PutMetadata:input_type -> PutMetadataRequest
-	6,  // 5: RatingService.GetAggregatedRating:input_type -> GetAggregatedRatingRequest
-	8,  // 6: RatingService.PutRating:input_type -> PutRatingRequest
-	10, // 7: MovieService.GetMovieDetails:input_type -> GetMovieDetailsRequest
-	3,  // 8: MetadataService.GetMetadata:output_type -> GetMetadataResponse
-	5,  // 9: MetadataService.PutMetadata:output_type -> PutMetadataResponse
-	7,  // 10: RatingService.GetAggregatedRating:output_type -> GetAggregatedRatingResponse
-	9,  // 11: RatingService.PutRating:output_type -> PutRatingResponse
-	11, // 12: MovieService.GetMovieDetails:output_type -> GetMovieDetailsResponse
-	8,  // [8:13] is the sub-list for method output_type
-	3,  // [3:8] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	2,  // 0: MovieDetails.metadata:type_name -> Metadata
+	34, // 1: RatingDistribution.counts:type_name -> RatingDistribution.CountsEntry
+	2,  // 2: GetMetadataResponse.metadata:type_name -> Metadata
+	2,  // 3: BatchGetMetadataResponse.metadata:type_name -> Metadata
+	0,  // 4: ListMetadataRequest.order:type_name -> MetadataOrder
+	2,  // 5: ListMetadataResponse.metadata:type_name -> Metadata
+	4,  // 6: BatchGetAggregatedRatingsResponse.ratings:type_name -> AggregatedRating
+	4,  // 7: ListAggregatedRatingsResponse.ratings:type_name -> AggregatedRating
+	5,  // 8: BatchGetRatingDistributionsResponse.distributions:type_name -> RatingDistribution
+	3,  // 9: GetMovieDetailsResponse.movie_details:type_name -> MovieDetails
+	3,  // 10: MovieDetailsResult.movie_details:type_name -> MovieDetails
+	27, // 11: BatchGetMovieDetailsResponse.results:type_name -> MovieDetailsResult
+	1,  // 12: ListMoviesRequest.sort:type_name -> MovieSort
+	3,  // 13: ListMoviesResponse.movies:type_name -> MovieDetails
+	3,  // 14: SimilarMovie.movie_details:type_name -> MovieDetails
+	32, // 15: GetSimilarMoviesResponse.movies:type_name -> SimilarMovie
+	6,  // 16: MetadataService.GetMetadata:input_type -> GetMetadataRequest
+	8,  // 17: MetadataService.PutMetadata:input_type -> PutMetadataRequest
+	10, // 18: MetadataService.BatchGetMetadata:input_type -> BatchGetMetadataRequest
+	12, // 19: MetadataService.ListMetadata:input_type -> ListMetadataRequest
+	14, // 20: RatingService.GetAggregatedRating:input_type -> GetAggregatedRatingRequest
+	16, // 21: RatingService.PutRating:input_type -> PutRatingRequest
+	18, // 22: RatingService.BatchGetAggregatedRatings:input_type -> BatchGetAggregatedRatingsRequest
+	20, // 23: RatingService.ListAggregatedRatings:input_type -> ListAggregatedRatingsRequest
+	22, // 24: RatingService.BatchGetRatingDistributions:input_type -> BatchGetRatingDistributionsRequest
+	24, // 25: MovieService.GetMovieDetails:input_type -> GetMovieDetailsRequest
+	26, // 26: MovieService.BatchGetMovieDetails:input_type -> BatchGetMovieDetailsRequest
+	29, // 27: MovieService.ListMovies:input_type -> ListMoviesRequest
+	16, // 28: MovieService.PutRating:input_type -> PutRatingRequest
+	31, // 29: MovieService.GetSimilarMovies:input_type -> GetSimilarMoviesRequest
+	7,  // 30: MetadataService.GetMetadata:output_type -> GetMetadataResponse
+	9,  // 31: MetadataService.PutMetadata:output_type -> PutMetadataResponse
+	11, // 32: MetadataService.BatchGetMetadata:output_type -> BatchGetMetadataResponse
+	13, // 33: MetadataService.ListMetadata:output_type -> ListMetadataResponse
+	15, // 34: RatingService.GetAggregatedRating:output_type -> GetAggregatedRatingResponse
+	17, // 35: RatingService.PutRating:output_type -> PutRatingResponse
+	19, // 36: RatingService.BatchGetAggregatedRatings:output_type -> BatchGetAggregatedRatingsResponse
+	21, // 37: RatingService.ListAggregatedRatings:output_type -> ListAggregatedRatingsResponse
+	23, // 38: RatingService.BatchGetRatingDistributions:output_type -> BatchGetRatingDistributionsResponse
+	25, // 39: MovieService.GetMovieDetails:output_type -> GetMovieDetailsResponse
+	28, // 40: MovieService.BatchGetMovieDetails:output_type -> BatchGetMovieDetailsResponse
+	30, // 41: MovieService.ListMovies:output_type -> ListMoviesResponse
+	17, // 42: MovieService.PutRating:output_type -> PutRatingResponse
+	33, // 43: MovieService.GetSimilarMovies:output_type -> GetSimilarMoviesResponse
+	30, // [30:44] is the sub-list for method output_type
+	16, // [16:30] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_movie_proto_init() }
@@ -710,13 +1961,14 @@ func file_movie_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_movie_proto_rawDesc), len(file_movie_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   12,
+			NumEnums:      2,
+			NumMessages:   33,
 			NumExtensions: 0,
 			NumServices:   3,
 		},
 		GoTypes:           file_movie_proto_goTypes,
 		DependencyIndexes: file_movie_proto_depIdxs,
+		EnumInfos:         file_movie_proto_enumTypes,
 		MessageInfos:      file_movie_proto_msgTypes,
 	}.Build()
 	File_movie_proto = out.File
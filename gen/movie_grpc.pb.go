@@ -19,8 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MetadataService_GetMetadata_FullMethodName = "/MetadataService/GetMetadata"
-	MetadataService_PutMetadata_FullMethodName = "/MetadataService/PutMetadata"
+	MetadataService_GetMetadata_FullMethodName      = "/MetadataService/GetMetadata"
+	MetadataService_PutMetadata_FullMethodName      = "/MetadataService/PutMetadata"
+	MetadataService_BatchGetMetadata_FullMethodName = "/MetadataService/BatchGetMetadata"
+	MetadataService_ListMetadata_FullMethodName     = "/MetadataService/ListMetadata"
 )
 
 // MetadataServiceClient is the client API for MetadataService service.
@@ -29,6 +31,8 @@ const (
 type MetadataServiceClient interface {
 	GetMetadata(ctx context.Context, in *GetMetadataRequest, opts ...grpc.CallOption) (*GetMetadataResponse, error)
 	PutMetadata(ctx context.Context, in *PutMetadataRequest, opts ...grpc.CallOption) (*PutMetadataResponse, error)
+	BatchGetMetadata(ctx context.Context, in *BatchGetMetadataRequest, opts ...grpc.CallOption) (*BatchGetMetadataResponse, error)
+	ListMetadata(ctx context.Context, in *ListMetadataRequest, opts ...grpc.CallOption) (*ListMetadataResponse, error)
 }
 
 type metadataServiceClient struct {
@@ -59,12 +63,34 @@ func (c *metadataServiceClient) PutMetadata(ctx context.Context, in *PutMetadata
 	return out, nil
 }
 
+func (c *metadataServiceClient) BatchGetMetadata(ctx context.Context, in *BatchGetMetadataRequest, opts ...grpc.CallOption) (*BatchGetMetadataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetMetadataResponse)
+	err := c.cc.Invoke(ctx, MetadataService_BatchGetMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) ListMetadata(ctx context.Context, in *ListMetadataRequest, opts ...grpc.CallOption) (*ListMetadataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMetadataResponse)
+	err := c.cc.Invoke(ctx, MetadataService_ListMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetadataServiceServer is the server API for MetadataService service.
 // All implementations must embed UnimplementedMetadataServiceServer
 // for forward compatibility.
 type MetadataServiceServer interface {
 	GetMetadata(context.Context, *GetMetadataRequest) (*GetMetadataResponse, error)
 	PutMetadata(context.Context, *PutMetadataRequest) (*PutMetadataResponse, error)
+	BatchGetMetadata(context.Context, *BatchGetMetadataRequest) (*BatchGetMetadataResponse, error)
+	ListMetadata(context.Context, *ListMetadataRequest) (*ListMetadataResponse, error)
 	mustEmbedUnimplementedMetadataServiceServer()
 }
 
@@ -81,6 +107,12 @@ func (UnimplementedMetadataServiceServer) GetMetadata(context.Context, *GetMetad
 func (UnimplementedMetadataServiceServer) PutMetadata(context.Context, *PutMetadataRequest) (*PutMetadataResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method PutMetadata not implemented")
 }
+func (UnimplementedMetadataServiceServer) BatchGetMetadata(context.Context, *BatchGetMetadataRequest) (*BatchGetMetadataResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchGetMetadata not implemented")
+}
+func (UnimplementedMetadataServiceServer) ListMetadata(context.Context, *ListMetadataRequest) (*ListMetadataResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListMetadata not implemented")
+}
 func (UnimplementedMetadataServiceServer) mustEmbedUnimplementedMetadataServiceServer() {}
 func (UnimplementedMetadataServiceServer) testEmbeddedByValue()                         {}
 
@@ -138,6 +170,42 @@ func _MetadataService_PutMetadata_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MetadataService_BatchGetMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).BatchGetMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_BatchGetMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).BatchGetMetadata(ctx, req.(*BatchGetMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_ListMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).ListMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_ListMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).ListMetadata(ctx, req.(*ListMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MetadataService_ServiceDesc is the grpc.ServiceDesc for MetadataService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,14 +221,25 @@ var MetadataService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PutMetadata",
 			Handler:    _MetadataService_PutMetadata_Handler,
 		},
+		{
+			MethodName: "BatchGetMetadata",
+			Handler:    _MetadataService_BatchGetMetadata_Handler,
+		},
+		{
+			MethodName: "ListMetadata",
+			Handler:    _MetadataService_ListMetadata_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "movie.proto",
 }
 
 const (
-	RatingService_GetAggregatedRating_FullMethodName = "/RatingService/GetAggregatedRating"
-	RatingService_PutRating_FullMethodName           = "/RatingService/PutRating"
+	RatingService_GetAggregatedRating_FullMethodName         = "/RatingService/GetAggregatedRating"
+	RatingService_PutRating_FullMethodName                   = "/RatingService/PutRating"
+	RatingService_BatchGetAggregatedRatings_FullMethodName   = "/RatingService/BatchGetAggregatedRatings"
+	RatingService_ListAggregatedRatings_FullMethodName       = "/RatingService/ListAggregatedRatings"
+	RatingService_BatchGetRatingDistributions_FullMethodName = "/RatingService/BatchGetRatingDistributions"
 )
 
 // RatingServiceClient is the client API for RatingService service.
@@ -169,6 +248,9 @@ const (
 type RatingServiceClient interface {
 	GetAggregatedRating(ctx context.Context, in *GetAggregatedRatingRequest, opts ...grpc.CallOption) (*GetAggregatedRatingResponse, error)
 	PutRating(ctx context.Context, in *PutRatingRequest, opts ...grpc.CallOption) (*PutRatingResponse, error)
+	BatchGetAggregatedRatings(ctx context.Context, in *BatchGetAggregatedRatingsRequest, opts ...grpc.CallOption) (*BatchGetAggregatedRatingsResponse, error)
+	ListAggregatedRatings(ctx context.Context, in *ListAggregatedRatingsRequest, opts ...grpc.CallOption) (*ListAggregatedRatingsResponse, error)
+	BatchGetRatingDistributions(ctx context.Context, in *BatchGetRatingDistributionsRequest, opts ...grpc.CallOption) (*BatchGetRatingDistributionsResponse, error)
 }
 
 type ratingServiceClient struct {
@@ -199,12 +281,45 @@ func (c *ratingServiceClient) PutRating(ctx context.Context, in *PutRatingReques
 	return out, nil
 }
 
+func (c *ratingServiceClient) BatchGetAggregatedRatings(ctx context.Context, in *BatchGetAggregatedRatingsRequest, opts ...grpc.CallOption) (*BatchGetAggregatedRatingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetAggregatedRatingsResponse)
+	err := c.cc.Invoke(ctx, RatingService_BatchGetAggregatedRatings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ratingServiceClient) ListAggregatedRatings(ctx context.Context, in *ListAggregatedRatingsRequest, opts ...grpc.CallOption) (*ListAggregatedRatingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAggregatedRatingsResponse)
+	err := c.cc.Invoke(ctx, RatingService_ListAggregatedRatings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ratingServiceClient) BatchGetRatingDistributions(ctx context.Context, in *BatchGetRatingDistributionsRequest, opts ...grpc.CallOption) (*BatchGetRatingDistributionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetRatingDistributionsResponse)
+	err := c.cc.Invoke(ctx, RatingService_BatchGetRatingDistributions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RatingServiceServer is the server API for RatingService service.
 // All implementations must embed UnimplementedRatingServiceServer
 // for forward compatibility.
 type RatingServiceServer interface {
 	GetAggregatedRating(context.Context, *GetAggregatedRatingRequest) (*GetAggregatedRatingResponse, error)
 	PutRating(context.Context, *PutRatingRequest) (*PutRatingResponse, error)
+	BatchGetAggregatedRatings(context.Context, *BatchGetAggregatedRatingsRequest) (*BatchGetAggregatedRatingsResponse, error)
+	ListAggregatedRatings(context.Context, *ListAggregatedRatingsRequest) (*ListAggregatedRatingsResponse, error)
+	BatchGetRatingDistributions(context.Context, *BatchGetRatingDistributionsRequest) (*BatchGetRatingDistributionsResponse, error)
 	mustEmbedUnimplementedRatingServiceServer()
 }
 
@@ -221,6 +336,15 @@ func (UnimplementedRatingServiceServer) GetAggregatedRating(context.Context, *Ge
 func (UnimplementedRatingServiceServer) PutRating(context.Context, *PutRatingRequest) (*PutRatingResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method PutRating not implemented")
 }
+func (UnimplementedRatingServiceServer) BatchGetAggregatedRatings(context.Context, *BatchGetAggregatedRatingsRequest) (*BatchGetAggregatedRatingsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchGetAggregatedRatings not implemented")
+}
+func (UnimplementedRatingServiceServer) ListAggregatedRatings(context.Context, *ListAggregatedRatingsRequest) (*ListAggregatedRatingsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAggregatedRatings not implemented")
+}
+func (UnimplementedRatingServiceServer) BatchGetRatingDistributions(context.Context, *BatchGetRatingDistributionsRequest) (*BatchGetRatingDistributionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchGetRatingDistributions not implemented")
+}
 func (UnimplementedRatingServiceServer) mustEmbedUnimplementedRatingServiceServer() {}
 func (UnimplementedRatingServiceServer) testEmbeddedByValue()                       {}
 
@@ -278,6 +402,60 @@ func _RatingService_PutRating_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RatingService_BatchGetAggregatedRatings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetAggregatedRatingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RatingServiceServer).BatchGetAggregatedRatings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RatingService_BatchGetAggregatedRatings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RatingServiceServer).BatchGetAggregatedRatings(ctx, req.(*BatchGetAggregatedRatingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RatingService_ListAggregatedRatings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAggregatedRatingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RatingServiceServer).ListAggregatedRatings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RatingService_ListAggregatedRatings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RatingServiceServer).ListAggregatedRatings(ctx, req.(*ListAggregatedRatingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RatingService_BatchGetRatingDistributions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetRatingDistributionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RatingServiceServer).BatchGetRatingDistributions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RatingService_BatchGetRatingDistributions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RatingServiceServer).BatchGetRatingDistributions(ctx, req.(*BatchGetRatingDistributionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RatingService_ServiceDesc is the grpc.ServiceDesc for RatingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -293,13 +471,29 @@ var RatingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PutRating",
 			Handler:    _RatingService_PutRating_Handler,
 		},
+		{
+			MethodName: "BatchGetAggregatedRatings",
+			Handler:    _RatingService_BatchGetAggregatedRatings_Handler,
+		},
+		{
+			MethodName: "ListAggregatedRatings",
+			Handler:    _RatingService_ListAggregatedRatings_Handler,
+		},
+		{
+			MethodName: "BatchGetRatingDistributions",
+			Handler:    _RatingService_BatchGetRatingDistributions_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "movie.proto",
 }
 
 const (
-	MovieService_GetMovieDetails_FullMethodName = "/MovieService/GetMovieDetails"
+	MovieService_GetMovieDetails_FullMethodName      = "/MovieService/GetMovieDetails"
+	MovieService_BatchGetMovieDetails_FullMethodName = "/MovieService/BatchGetMovieDetails"
+	MovieService_ListMovies_FullMethodName           = "/MovieService/ListMovies"
+	MovieService_PutRating_FullMethodName            = "/MovieService/PutRating"
+	MovieService_GetSimilarMovies_FullMethodName     = "/MovieService/GetSimilarMovies"
 )
 
 // MovieServiceClient is the client API for MovieService service.
@@ -307,6 +501,10 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MovieServiceClient interface {
 	GetMovieDetails(ctx context.Context, in *GetMovieDetailsRequest, opts ...grpc.CallOption) (*GetMovieDetailsResponse, error)
+	BatchGetMovieDetails(ctx context.Context, in *BatchGetMovieDetailsRequest, opts ...grpc.CallOption) (*BatchGetMovieDetailsResponse, error)
+	ListMovies(ctx context.Context, in *ListMoviesRequest, opts ...grpc.CallOption) (*ListMoviesResponse, error)
+	PutRating(ctx context.Context, in *PutRatingRequest, opts ...grpc.CallOption) (*PutRatingResponse, error)
+	GetSimilarMovies(ctx context.Context, in *GetSimilarMoviesRequest, opts ...grpc.CallOption) (*GetSimilarMoviesResponse, error)
 }
 
 type movieServiceClient struct {
@@ -327,11 +525,55 @@ func (c *movieServiceClient) GetMovieDetails(ctx context.Context, in *GetMovieDe
 	return out, nil
 }
 
+func (c *movieServiceClient) BatchGetMovieDetails(ctx context.Context, in *BatchGetMovieDetailsRequest, opts ...grpc.CallOption) (*BatchGetMovieDetailsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetMovieDetailsResponse)
+	err := c.cc.Invoke(ctx, MovieService_BatchGetMovieDetails_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *movieServiceClient) ListMovies(ctx context.Context, in *ListMoviesRequest, opts ...grpc.CallOption) (*ListMoviesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMoviesResponse)
+	err := c.cc.Invoke(ctx, MovieService_ListMovies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *movieServiceClient) PutRating(ctx context.Context, in *PutRatingRequest, opts ...grpc.CallOption) (*PutRatingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutRatingResponse)
+	err := c.cc.Invoke(ctx, MovieService_PutRating_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *movieServiceClient) GetSimilarMovies(ctx context.Context, in *GetSimilarMoviesRequest, opts ...grpc.CallOption) (*GetSimilarMoviesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSimilarMoviesResponse)
+	err := c.cc.Invoke(ctx, MovieService_GetSimilarMovies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MovieServiceServer is the server API for MovieService service.
 // All implementations must embed UnimplementedMovieServiceServer
 // for forward compatibility.
 type MovieServiceServer interface {
 	GetMovieDetails(context.Context, *GetMovieDetailsRequest) (*GetMovieDetailsResponse, error)
+	BatchGetMovieDetails(context.Context, *BatchGetMovieDetailsRequest) (*BatchGetMovieDetailsResponse, error)
+	ListMovies(context.Context, *ListMoviesRequest) (*ListMoviesResponse, error)
+	PutRating(context.Context, *PutRatingRequest) (*PutRatingResponse, error)
+	GetSimilarMovies(context.Context, *GetSimilarMoviesRequest) (*GetSimilarMoviesResponse, error)
 	mustEmbedUnimplementedMovieServiceServer()
 }
 
@@ -345,6 +587,18 @@ type UnimplementedMovieServiceServer struct{}
 func (UnimplementedMovieServiceServer) GetMovieDetails(context.Context, *GetMovieDetailsRequest) (*GetMovieDetailsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetMovieDetails not implemented")
 }
+func (UnimplementedMovieServiceServer) BatchGetMovieDetails(context.Context, *BatchGetMovieDetailsRequest) (*BatchGetMovieDetailsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchGetMovieDetails not implemented")
+}
+func (UnimplementedMovieServiceServer) ListMovies(context.Context, *ListMoviesRequest) (*ListMoviesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListMovies not implemented")
+}
+func (UnimplementedMovieServiceServer) PutRating(context.Context, *PutRatingRequest) (*PutRatingResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PutRating not implemented")
+}
+func (UnimplementedMovieServiceServer) GetSimilarMovies(context.Context, *GetSimilarMoviesRequest) (*GetSimilarMoviesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSimilarMovies not implemented")
+}
 func (UnimplementedMovieServiceServer) mustEmbedUnimplementedMovieServiceServer() {}
 func (UnimplementedMovieServiceServer) testEmbeddedByValue()                      {}
 
@@ -384,6 +638,78 @@ func _MovieService_GetMovieDetails_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MovieService_BatchGetMovieDetails_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetMovieDetailsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).BatchGetMovieDetails(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_BatchGetMovieDetails_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).BatchGetMovieDetails(ctx, req.(*BatchGetMovieDetailsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MovieService_ListMovies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMoviesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).ListMovies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_ListMovies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).ListMovies(ctx, req.(*ListMoviesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MovieService_PutRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).PutRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_PutRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).PutRating(ctx, req.(*PutRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MovieService_GetSimilarMovies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSimilarMoviesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).GetSimilarMovies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_GetSimilarMovies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).GetSimilarMovies(ctx, req.(*GetSimilarMoviesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MovieService_ServiceDesc is the grpc.ServiceDesc for MovieService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -395,6 +721,22 @@ var MovieService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMovieDetails",
 			Handler:    _MovieService_GetMovieDetails_Handler,
 		},
+		{
+			MethodName: "BatchGetMovieDetails",
+			Handler:    _MovieService_BatchGetMovieDetails_Handler,
+		},
+		{
+			MethodName: "ListMovies",
+			Handler:    _MovieService_ListMovies_Handler,
+		},
+		{
+			MethodName: "PutRating",
+			Handler:    _MovieService_PutRating_Handler,
+		},
+		{
+			MethodName: "GetSimilarMovies",
+			Handler:    _MovieService_GetSimilarMovies_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "movie.proto",
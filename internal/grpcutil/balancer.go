@@ -0,0 +1,221 @@
+package grpcutil
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// Policy is a client-side load balancing policy for inter-service calls.
+// Instances with a weight in their metadata (discovery.MetaWeight) get a
+// share of calls proportional to it, except under PolicyRoundRobin.
+type Policy string
+
+const (
+	// PolicyRoundRobin spreads calls evenly across instances.
+	PolicyRoundRobin Policy = "round_robin"
+	// PolicyLeastRequest picks two random instances and sends the call to
+	// the one with fewer outstanding requests per unit of weight.
+	PolicyLeastRequest Policy = "p2c_least_request"
+	// PolicyConsistentHash sends calls with the same hash key (see
+	// WithHashKey) to the same instance, so per-key caches stay warm.
+	PolicyConsistentHash Policy = "consistent_hash"
+)
+
+// ringReplicas is the average number of points an instance gets on the
+// hash ring. Each instance gets a number proportional to its weight.
+const ringReplicas = 100
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(string(PolicyLeastRequest), &leastRequestPickerBuilder{}, base.Config{HealthCheck: true}))
+	balancer.Register(base.NewBalancerBuilder(string(PolicyConsistentHash), &consistentHashPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+func (p Policy) serviceConfig() (string, error) {
+	switch p {
+	case "":
+		p = PolicyRoundRobin
+	case PolicyRoundRobin, PolicyLeastRequest, PolicyConsistentHash:
+	default:
+		return "", fmt.Errorf("unknown load balancing policy %q", p)
+	}
+	// Instances reporting NOT_SERVING on the gRPC health service are skipped.
+	return fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}], "healthCheckConfig": {"serviceName": ""}}`, p), nil
+}
+
+type hashKey struct{}
+
+// WithHashKey returns a context whose calls are routed by key when the
+// connection uses PolicyConsistentHash.
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey{}, key)
+}
+
+type leastRequestPickerBuilder struct{}
+
+// Build starts every instance from zero outstanding requests, so counts are
+// briefly inaccurate after the set of ready instances changes.
+func (b *leastRequestPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &leastRequestPicker{}
+	for sc, sci := range info.ReadySCs {
+		p.subConns = append(p.subConns, &countedSubConn{SubConn: sc, addr: sci.Address.Addr, weight: addressWeight(sci.Address)})
+	}
+	return p
+}
+
+type countedSubConn struct {
+	balancer.SubConn
+	addr        string
+	weight      int
+	outstanding atomic.Int64
+}
+
+// load returns the outstanding requests of the instance per unit of weight.
+func (sc *countedSubConn) load() float64 {
+	return float64(sc.outstanding.Load()) / float64(sc.weight)
+}
+
+type leastRequestPicker struct {
+	subConns []*countedSubConn
+}
+
+func (p *leastRequestPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	tracker := trackerFrom(info.Ctx)
+	subConns := p.subConns
+	if tracker != nil {
+		subConns = unpicked(tracker, subConns, func(sc *countedSubConn) string { return sc.addr })
+	}
+
+	weight := func(sc *countedSubConn) int { return sc.weight }
+	sc := pickWeighted(subConns, weight)
+	if len(subConns) > 1 {
+		other := pickWeighted(subConns, weight)
+		if other.load() < sc.load() {
+			sc = other
+		}
+	}
+	tracker.add(sc.addr)
+
+	sc.outstanding.Add(1)
+	return balancer.PickResult{
+		SubConn: sc.SubConn,
+		Done:    func(balancer.DoneInfo) { sc.outstanding.Add(-1) },
+	}, nil
+}
+
+type consistentHashPickerBuilder struct{}
+
+func (b *consistentHashPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	totalWeight := 0
+	for _, sci := range info.ReadySCs {
+		totalWeight += addressWeight(sci.Address)
+	}
+
+	p := &consistentHashPicker{}
+	for sc, sci := range info.ReadySCs {
+		weight := addressWeight(sci.Address)
+		p.subConns = append(p.subConns, ringPoint{subConn: sc, addr: sci.Address.Addr, weight: weight})
+
+		// The ring keeps about ringReplicas points per instance however
+		// large the weights are.
+		replicas := max(1, ringReplicas*len(info.ReadySCs)*weight/totalWeight)
+		for i := range replicas {
+			h := crc32.ChecksumIEEE([]byte(sci.Address.Addr + "#" + strconv.Itoa(i)))
+			p.ring = append(p.ring, ringPoint{h, sc, sci.Address.Addr, weight})
+		}
+	}
+	slices.SortFunc(p.ring, func(a, b ringPoint) int {
+		return int(int64(a.hash) - int64(b.hash))
+	})
+
+	return p
+}
+
+type ringPoint struct {
+	hash    uint32
+	subConn balancer.SubConn
+	addr    string
+	weight  int
+}
+
+type consistentHashPicker struct {
+	ring     []ringPoint
+	subConns []ringPoint
+}
+
+// Pick routes calls without a hash key to a random instance, chosen by
+// weight. Hedged copies of a call go to the next instance on the ring.
+func (p *consistentHashPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	tracker := trackerFrom(info.Ctx)
+
+	key, ok := info.Ctx.Value(hashKey{}).(string)
+	if !ok {
+		subConns := p.subConns
+		if tracker != nil {
+			subConns = unpicked(tracker, subConns, func(p ringPoint) string { return p.addr })
+		}
+		point := pickWeighted(subConns, func(p ringPoint) int { return p.weight })
+		tracker.add(point.addr)
+		return balancer.PickResult{SubConn: point.subConn}, nil
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	i, _ := slices.BinarySearchFunc(p.ring, h, func(p ringPoint, h uint32) int {
+		return int(int64(p.hash) - int64(h))
+	})
+
+	point := p.ring[i%len(p.ring)]
+	for j := range len(p.ring) {
+		if next := p.ring[(i+j)%len(p.ring)]; !tracker.has(next.addr) {
+			point = next
+			break
+		}
+	}
+	tracker.add(point.addr)
+
+	return balancer.PickResult{SubConn: point.subConn}, nil
+}
+
+// pickWeighted returns a random instance, each with a chance proportional
+// to its weight.
+func pickWeighted[T any](subConns []T, weight func(T) int) T {
+	total := 0
+	for _, sc := range subConns {
+		total += weight(sc)
+	}
+
+	n := rand.IntN(total)
+	for _, sc := range subConns {
+		if n -= weight(sc); n < 0 {
+			return sc
+		}
+	}
+	return subConns[len(subConns)-1]
+}
+
+// unpicked returns the instances the tracker has not seen yet, or all of
+// them if it has seen every one.
+func unpicked[T any](tracker *pickTracker, subConns []T, addr func(T) string) []T {
+	res := slices.DeleteFunc(slices.Clone(subConns), func(sc T) bool {
+		return tracker.has(addr(sc))
+	})
+	if len(res) == 0 {
+		return subConns
+	}
+	return res
+}
@@ -0,0 +1,109 @@
+package grpcutil
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+// buildInfo returns ready instances at the given addresses with the given
+// weights.
+func buildInfo(weights map[string]int) base.PickerBuildInfo {
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for addr, w := range weights {
+		info.ReadySCs[&fakeSubConn{addr: addr}] = base.SubConnInfo{Address: withWeight(resolver.Address{Addr: addr}, w)}
+	}
+	return info
+}
+
+// shares picks n times with contexts from ctx and returns the share of
+// picks each address got.
+func shares(t *testing.T, p balancer.Picker, n int, ctx func(i int) context.Context) map[string]float64 {
+	t.Helper()
+
+	counts := make(map[string]int)
+	for i := range n {
+		res, err := p.Pick(balancer.PickInfo{Ctx: ctx(i)})
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		counts[res.SubConn.(*fakeSubConn).addr]++
+		if res.Done != nil {
+			res.Done(balancer.DoneInfo{})
+		}
+	}
+
+	res := make(map[string]float64, len(counts))
+	for addr, c := range counts {
+		res[addr] = float64(c) / float64(n)
+	}
+	return res
+}
+
+func checkShares(t *testing.T, got, want map[string]float64) {
+	t.Helper()
+	for addr, w := range want {
+		if math.Abs(got[addr]-w) > 0.05 {
+			t.Errorf("share of %s: got %.3f, want %.3f", addr, got[addr], w)
+		}
+	}
+}
+
+func TestPickersHonourWeights(t *testing.T) {
+	weights := map[string]int{"a:1": 1, "b:1": 3}
+	want := map[string]float64{"a:1": 0.25, "b:1": 0.75}
+	noKey := func(int) context.Context { return context.Background() }
+
+	t.Run("least request", func(t *testing.T) {
+		p := (&leastRequestPickerBuilder{}).Build(buildInfo(weights))
+		checkShares(t, shares(t, p, 20000, noKey), want)
+	})
+
+	t.Run("consistent hash", func(t *testing.T) {
+		p := (&consistentHashPickerBuilder{}).Build(buildInfo(weights))
+		checkShares(t, shares(t, p, 20000, func(i int) context.Context {
+			return WithHashKey(context.Background(), strconv.Itoa(i))
+		}), want)
+	})
+
+	t.Run("consistent hash without key", func(t *testing.T) {
+		p := (&consistentHashPickerBuilder{}).Build(buildInfo(weights))
+		checkShares(t, shares(t, p, 20000, noKey), want)
+	})
+}
+
+func TestLeastRequestPrefersLessLoadPerWeight(t *testing.T) {
+	p := (&leastRequestPickerBuilder{}).Build(buildInfo(map[string]int{"a:1": 1, "b:1": 4})).(*leastRequestPicker)
+
+	// Keep every call outstanding, so the load shapes the picks.
+	counts := make(map[string]int)
+	for range 5000 {
+		res, err := p.Pick(balancer.PickInfo{Ctx: context.Background()})
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		counts[res.SubConn.(*fakeSubConn).addr]++
+	}
+
+	if ratio := float64(counts["b:1"]) / float64(counts["a:1"]); ratio < 3.5 || ratio > 4.5 {
+		t.Errorf("outstanding calls of b:1 per a:1: got %.2f, want about 4", ratio)
+	}
+}
+
+func TestConsistentHashRingSize(t *testing.T) {
+	// DNS weights go up to 65535, which must not blow up the ring.
+	p := (&consistentHashPickerBuilder{}).Build(buildInfo(map[string]int{"a:1": 1, "b:1": 65535})).(*consistentHashPicker)
+	if n := len(p.ring); n > 2*ringReplicas+1 {
+		t.Errorf("ring points: got %d, want at most %d", n, 2*ringReplicas+1)
+	}
+}
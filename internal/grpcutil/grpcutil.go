@@ -1,20 +1,27 @@
 package grpcutil
 
 import (
-	"context"
-	"math/rand"
-
-	"github.com/ochamekan/ms/pkg/discovery"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // client-side health checking
+	"google.golang.org/grpc/resolver"
 )
 
-// ServiceConnection attempts to select a random service
-// instance and returns a gRPC connection to it.
-func ServiceConnection(ctx context.Context, serviceName string, registry discovery.Registry) (*grpc.ClientConn, error) {
-	addrs, err := registry.ServiceAddresses(ctx, serviceName)
+// ServiceConnection returns a long-lived gRPC connection to all instances
+// of the given service, resolved through the given resolver builder and
+// load balanced with the given policy, round robin if empty. It is meant
+// to be created once and shared.
+func ServiceConnection(serviceName string, builder resolver.Builder, policy Policy, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	serviceConfig, err := policy.serviceConfig()
 	if err != nil {
 		return nil, err
 	}
-	return grpc.NewClient(addrs[rand.Intn(len(addrs))], grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+	opts = append([]grpc.DialOption{
+		grpc.WithResolvers(builder),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+	}, opts...)
+
+	return grpc.NewClient(builder.Scheme()+":///"+serviceName, opts...)
 }
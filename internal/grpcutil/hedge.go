@@ -0,0 +1,210 @@
+package grpcutil
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// latencySamples is the number of recent latencies per method the hedge
+	// delay is computed from.
+	latencySamples = 256
+	// minLatencySamples is the number of latencies needed before calls are
+	// hedged at all.
+	minLatencySamples = 20
+	// delayRefreshEvery is how many new latencies it takes to recompute the
+	// hedge delay.
+	delayRefreshEvery = 16
+	// hedgeBudgetRatio caps hedges to this fraction of calls, so that
+	// hedging cannot double the load on a service that slows down.
+	hedgeBudgetRatio = 0.1
+)
+
+// HedgeInterceptor returns a client interceptor that sends a second copy of
+// a call to another instance when the first has not returned within the
+// given percentile, e.g. 0.95, of the method's recent latencies. The first
+// successful response wins and the other call is cancelled. Only the given
+// methods, which must be safe to run twice, are hedged, and hedges are
+// capped to hedgeBudgetRatio of the calls on the connection.
+//
+// The delay is derived from the latencies of first copies only. A first
+// copy that runs out of time, or loses to its hedge, is counted with the
+// time it took until then, so that slow instances raise the delay rather
+// than go unnoticed.
+//
+// Only PolicyLeastRequest and PolicyConsistentHash send the copies of a
+// call to different instances, so the interceptor must not be used with
+// PolicyRoundRobin.
+func HedgeInterceptor(percentile float64, methods []string, m *metrics.Metrics) grpc.UnaryClientInterceptor {
+	latencies := make(map[string]*latencyWindow, len(methods))
+	for _, method := range methods {
+		latencies[method] = &latencyWindow{percentile: percentile}
+	}
+	budget := newBudget(hedgeBudgetRatio)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		window, ok := latencies[method]
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		budget.deposit()
+
+		start := time.Now()
+		delay, ok := window.delay()
+		if !ok {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if timed(err) {
+				window.observe(time.Since(start))
+			}
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(withPickTracker(ctx))
+		defer cancel()
+
+		type result struct {
+			reply  proto.Message
+			err    error
+			hedged bool
+		}
+		results := make(chan result, 2)
+		call := func(hedged bool) {
+			r := reply.(proto.Message).ProtoReflect().New().Interface()
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- result{r, err, hedged}
+		}
+
+		go call(false)
+		inflight, firstDone := 1, false
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		var err error
+		for inflight > 0 {
+			select {
+			case <-timer.C:
+				if !budget.withdraw() {
+					m.IncHedgeCount(method, metrics.HedgeSkipped)
+					continue
+				}
+				m.IncHedgeCount(method, metrics.HedgeSent)
+				go call(true)
+				inflight++
+			case res := <-results:
+				inflight--
+				if !res.hedged {
+					firstDone = true
+					if timed(res.err) {
+						window.observe(time.Since(start))
+					}
+				}
+				if res.err != nil {
+					// The other copy may still succeed. If the first fails
+					// before the delay, no hedge is sent and the call fails.
+					err = res.err
+					continue
+				}
+
+				if res.hedged {
+					m.IncHedgeCount(method, metrics.HedgeWon)
+				}
+				if !firstDone {
+					// The first copy took at least as long.
+					window.observe(time.Since(start))
+				}
+				proto.Merge(reply.(proto.Message), res.reply)
+				return nil
+			}
+		}
+		return err
+	}
+}
+
+// timed reports whether a call that ended with err took as long as the
+// instance needed, i.e. it succeeded or ran out of time.
+func timed(err error) bool {
+	return err == nil || status.Code(err) == codes.DeadlineExceeded
+}
+
+// latencyWindow keeps the recent latencies of a method and the hedge delay
+// derived from them.
+type latencyWindow struct {
+	percentile float64
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	added   int
+	cached  time.Duration
+}
+
+func (w *latencyWindow) observe(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < latencySamples {
+		w.samples = append(w.samples, d)
+	} else {
+		w.samples[w.next] = d
+		w.next = (w.next + 1) % latencySamples
+	}
+
+	w.added++
+	if len(w.samples) >= minLatencySamples && (w.cached == 0 || w.added%delayRefreshEvery == 0) {
+		sorted := slices.Sorted(slices.Values(w.samples))
+		w.cached = sorted[min(int(w.percentile*float64(len(sorted))), len(sorted)-1)]
+	}
+}
+
+// delay returns the hedge delay, or false while there are too few samples.
+func (w *latencyWindow) delay() (time.Duration, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cached, w.cached > 0
+}
+
+type pickTrackerKey struct{}
+
+// pickTracker records the instances picked for the copies of a hedged call,
+// so that the balancer can send each copy to a different instance.
+type pickTracker struct {
+	mu     sync.Mutex
+	picked []string
+}
+
+func withPickTracker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pickTrackerKey{}, &pickTracker{})
+}
+
+// trackerFrom returns the tracker of a hedged call, or nil.
+func trackerFrom(ctx context.Context) *pickTracker {
+	t, _ := ctx.Value(pickTrackerKey{}).(*pickTracker)
+	return t
+}
+
+func (t *pickTracker) has(addr string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Contains(t.picked, addr)
+}
+
+func (t *pickTracker) add(addr string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.picked = append(t.picked, addr)
+	t.mu.Unlock()
+}
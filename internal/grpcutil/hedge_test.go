@@ -0,0 +1,101 @@
+package grpcutil
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const hedgedMethod = "/test.Service/Get"
+
+// warmUp makes enough fast calls for the interceptor to start hedging.
+func warmUp(t *testing.T, hedge grpc.UnaryClientInterceptor) {
+	t.Helper()
+
+	fast := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}
+	for range minLatencySamples {
+		if err := hedge(context.Background(), hedgedMethod, nil, &wrapperspb.StringValue{}, nil, fast); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHedgeBudget(t *testing.T) {
+	hedge := HedgeInterceptor(0.5, []string{hedgedMethod}, metrics.New(prometheus.NewRegistry()))
+	warmUp(t, hedge)
+
+	// Every first copy is slow, so every call is due for a hedge.
+	var calls atomic.Int64
+	slowFirst := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if calls.Add(1) == 1 {
+			select {
+			case <-time.After(20 * time.Millisecond):
+			case <-ctx.Done():
+			}
+		}
+		return nil
+	}
+
+	const n = 100
+	var hedges int64
+	for range n {
+		calls.Store(0)
+		hedge(context.Background(), hedgedMethod, nil, &wrapperspb.StringValue{}, nil, slowFirst)
+		hedges += calls.Load() - 1
+	}
+	if hedges == 0 {
+		t.Fatal("no call was hedged")
+	}
+	if limit := int64(budgetCapacity + hedgeBudgetRatio*n); hedges > limit {
+		t.Errorf("hedges over %d calls: got %d, want at most %d", n, hedges, limit)
+	}
+}
+
+func TestHedgeDelayCountsTimedOutFirstCopies(t *testing.T) {
+	m := metrics.New(prometheus.NewRegistry())
+	hedge := HedgeInterceptor(0.5, []string{hedgedMethod}, m)
+	warmUp(t, hedge)
+
+	// The first copies time out after 30ms. Their hedges fail too, so that
+	// none wins.
+	timeout := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		select {
+		case <-time.After(30 * time.Millisecond):
+			return status.Error(codes.DeadlineExceeded, "timed out")
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	for range 2 * minLatencySamples {
+		hedge(context.Background(), hedgedMethod, nil, &wrapperspb.StringValue{}, nil, timeout)
+	}
+
+	// With most recent first copies taking 30ms, a 10ms call is neither
+	// hedged nor skipped for lack of budget.
+	hedges := func() float64 {
+		return testutil.ToFloat64(m.HedgedRequests.WithLabelValues(hedgedMethod, string(metrics.HedgeSent))) +
+			testutil.ToFloat64(m.HedgedRequests.WithLabelValues(hedgedMethod, string(metrics.HedgeSkipped)))
+	}
+	before := hedges()
+	medium := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+	hedge(context.Background(), hedgedMethod, nil, &wrapperspb.StringValue{}, nil, medium)
+
+	if hedges() != before {
+		t.Error("a 10ms call was due for a hedge")
+	}
+}
@@ -0,0 +1,182 @@
+package grpcutil
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/discovery"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the gRPC target scheme resolved through a discovery.Registry,
+// e.g. "registry:///metadata". Instances can be filtered with the query,
+// where "tag" lists required tags and any other key is matched against
+// instance metadata, e.g. "registry:///rating?tag=canary&zone=eu-west-1b".
+const Scheme = "registry"
+
+type resolverBuilder struct {
+	registry        discovery.Registry
+	refreshInterval time.Duration
+}
+
+// NewResolverBuilder returns a gRPC resolver builder that looks up service
+// instances in the registry. Registries implementing discovery.Watcher push
+// changes, others are polled every refreshInterval.
+func NewResolverBuilder(registry discovery.Registry, refreshInterval time.Duration) resolver.Builder {
+	return &resolverBuilder{registry, refreshInterval}
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &registryResolver{
+		registry:        b.registry,
+		serviceName:     target.Endpoint(),
+		filter:          targetFilter(target),
+		refreshInterval: b.refreshInterval,
+		cc:              cc,
+		cancel:          cancel,
+		resolveNow:      make(chan struct{}, 1),
+	}
+
+	r.wg.Go(func() { r.watch(ctx) })
+	return r, nil
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+func targetFilter(target resolver.Target) discovery.Filter {
+	var f discovery.Filter
+	for k, vs := range target.URL.Query() {
+		if k == "tag" {
+			f.Tags = append(f.Tags, vs...)
+			continue
+		}
+		if f.Meta == nil {
+			f.Meta = make(map[string]string)
+		}
+		f.Meta[k] = vs[0]
+	}
+	return f
+}
+
+type registryResolver struct {
+	registry        discovery.Registry
+	serviceName     string
+	filter          discovery.Filter
+	refreshInterval time.Duration
+	cc              resolver.ClientConn
+
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	resolveNow chan struct{}
+	addrs      []resolver.Address
+}
+
+// ResolveNow is called by gRPC when it wants a fresh address list, e.g.
+// after a connection to an instance fails.
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *registryResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *registryResolver) watch(ctx context.Context) {
+	if w, ok := r.registry.(discovery.Watcher); ok {
+		ch, err := w.Watch(ctx, r.serviceName)
+		if err == nil {
+			for instances := range ch {
+				r.push(r.filter.Apply(instances), nil)
+			}
+			return
+		}
+	}
+
+	ticker := time.NewTicker(r.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		r.update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.resolveNow:
+		}
+	}
+}
+
+func (r *registryResolver) update(ctx context.Context) {
+	r.push(r.registry.ServiceAddresses(ctx, r.serviceName, r.filter))
+}
+
+// push sends the given instances to gRPC. When the registry is unreachable
+// or has no instances the last known addresses are kept, and the error is
+// only reported if there never were any.
+func (r *registryResolver) push(instances []discovery.Instance, err error) {
+	if err == nil && len(instances) == 0 {
+		err = discovery.ErrNotFound
+	}
+	if err != nil {
+		if r.addrs == nil {
+			r.cc.ReportError(err)
+		}
+		return
+	}
+
+	addrs := make([]resolver.Address, len(instances))
+	for i, instance := range instances {
+		addrs[i] = withWeight(resolver.Address{Addr: instance.Address}, instanceWeight(instance))
+	}
+	slices.SortStableFunc(addrs, func(a, b resolver.Address) int {
+		return strings.Compare(a.Addr, b.Addr)
+	})
+	addrs = slices.CompactFunc(addrs, func(a, b resolver.Address) bool {
+		return a.Addr == b.Addr
+	})
+	if slices.EqualFunc(addrs, r.addrs, resolver.Address.Equal) {
+		return
+	}
+	r.addrs = addrs
+
+	r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+type weightKey struct{}
+
+// withWeight sets the share of calls the balancer sends to the address,
+// relative to the other addresses. A change of weight makes the address a
+// new one to gRPC, so its connection is replaced.
+func withWeight(addr resolver.Address, weight int) resolver.Address {
+	addr.Attributes = addr.Attributes.WithValue(weightKey{}, weight)
+	return addr
+}
+
+// addressWeight returns the weight of the address, 1 if it has none.
+func addressWeight(addr resolver.Address) int {
+	if w, ok := addr.Attributes.Value(weightKey{}).(int); ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// instanceWeight returns the weight in the metadata of an instance, 1 if it
+// has none or it is not positive.
+func instanceWeight(instance discovery.Instance) int {
+	if w, err := strconv.Atoi(instance.Meta[discovery.MetaWeight]); err == nil && w > 0 {
+		return w
+	}
+	return 1
+}
@@ -0,0 +1,124 @@
+package grpcutil
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// budgetCapacity is the number of retries or hedges that can be made in a
+// burst, e.g. right after startup, before a budget only refills with traffic.
+const budgetCapacity = 10
+
+// RetryPolicy describes how failed calls are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the first delay. It doubles with
+	// every attempt up to MaxBackoff, and the actual delay is picked at
+	// random below it.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableCodes are the status codes worth another attempt.
+	RetryableCodes []codes.Code
+	// NonIdempotent are the full names of methods that are never retried,
+	// because repeating them could apply a change twice.
+	NonIdempotent []string
+	// BudgetRatio caps retries to this fraction of calls.
+	BudgetRatio float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     1 * time.Second,
+	RetryableCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded},
+	BudgetRatio:    0.1,
+}
+
+// RetryInterceptor returns a client interceptor that retries failed calls
+// with exponential backoff and jitter. A retry is skipped when its delay
+// would not fit in the call's deadline, and once the connection's retry
+// budget is used up, so that retries cannot multiply the load on a service
+// that is already failing.
+func RetryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	budget := newBudget(policy.BudgetRatio)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		budget.deposit()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if slices.Contains(policy.NonIdempotent, method) {
+			return err
+		}
+
+		for attempt := 1; attempt < policy.MaxAttempts && policy.retryable(err); attempt++ {
+			delay := policy.backoff(attempt)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+				return err
+			}
+			if ctx.Err() != nil || !budget.withdraw() {
+				return err
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return err != nil && slices.Contains(p.RetryableCodes, status.Code(err))
+}
+
+// backoff returns the delay before the given retry, using full jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.InitialBackoff << (attempt - 1)
+	if ceiling <= 0 || ceiling > p.MaxBackoff {
+		ceiling = p.MaxBackoff
+	}
+	return rand.N(ceiling) + 1
+}
+
+// budget is a token bucket that gains a fraction of a token per call and
+// loses one per extra attempt, i.e. per retry or hedge.
+type budget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+func newBudget(ratio float64) *budget {
+	return &budget{ratio: ratio, tokens: budgetCapacity}
+}
+
+func (b *budget) deposit() {
+	b.mu.Lock()
+	b.tokens = min(b.tokens+b.ratio, budgetCapacity)
+	b.mu.Unlock()
+}
+
+func (b *budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,21 @@
+package grpcutil
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// TimeoutInterceptor returns a client interceptor that bounds every call,
+// and every retry of it when installed after RetryInterceptor, with the
+// given timeout. An earlier deadline already on the call's context, e.g.
+// the one of the inbound request, is kept.
+func TimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
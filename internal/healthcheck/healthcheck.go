@@ -0,0 +1,126 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	checkInterval = 2 * time.Second
+	checkTimeout  = 1 * time.Second
+)
+
+// Check returns an error if a dependency cannot be used.
+type Check func(ctx context.Context) error
+
+// Checker drives the serving status of a gRPC health server from
+// dependency checks. The named service is serving only while all checks
+// pass. The server as a whole, which Consul and clients check to route
+// calls, is serving while the checks of its hard dependencies pass: soft
+// dependencies, such as caches and downstream services whose failures are
+// degraded around, do not take every instance out of rotation when down.
+type Checker struct {
+	server      *health.Server
+	serviceName string
+	hard        map[string]Check
+	soft        map[string]Check
+	logger      *zap.Logger
+}
+
+// New returns a checker that reports the status of serviceName, and of
+// the server as a whole under "", on server.
+func New(server *health.Server, serviceName string, hard, soft map[string]Check, logger *zap.Logger) *Checker {
+	return &Checker{
+		server:      server,
+		serviceName: serviceName,
+		hard:        hard,
+		soft:        soft,
+		logger:      logger.With(zap.String(logging.FieldComponent, "health checker")),
+	}
+}
+
+// Run checks the dependencies every couple of seconds until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	var failing []string
+	for {
+		failing = c.check(ctx, failing)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs all checks, updates the serving status and returns the names of
+// the failing dependencies. Changes from the previous run are logged.
+func (c *Checker) check(ctx context.Context, prevFailing []string) []string {
+	hardFailing := c.run(ctx, c.hard, prevFailing, "Dependency check failed")
+	softFailing := c.run(ctx, c.soft, prevFailing, "Dependency check failed, serving degraded")
+
+	// A cancelled run says nothing about the dependencies.
+	if ctx.Err() != nil {
+		return prevFailing
+	}
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if len(hardFailing) > 0 {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	c.server.SetServingStatus("", status)
+	if len(softFailing) > 0 {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	c.server.SetServingStatus(c.serviceName, status)
+	return append(hardFailing, softFailing...)
+}
+
+// run runs the given checks and returns the names of the failing ones,
+// logging new failures with msg.
+func (c *Checker) run(ctx context.Context, checks map[string]Check, prevFailing []string, msg string) []string {
+	var failing []string
+	for _, name := range slices.Sorted(maps.Keys(checks)) {
+		cctx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := checks[name](cctx)
+		cancel()
+
+		if err != nil {
+			failing = append(failing, name)
+			if !slices.Contains(prevFailing, name) {
+				c.logger.Warn(msg, zap.String("dependency", name), zap.Error(err))
+			}
+		} else if slices.Contains(prevFailing, name) {
+			c.logger.Info("Dependency check recovered", zap.String("dependency", name))
+		}
+	}
+	return failing
+}
+
+// GRPC returns a check that passes while the service behind conn reports
+// itself as serving.
+func GRPC(conn *grpc.ClientConn) Check {
+	client := healthpb.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("status %s", resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	serving    = healthpb.HealthCheckResponse_SERVING
+	notServing = healthpb.HealthCheckResponse_NOT_SERVING
+)
+
+func pass(context.Context) error { return nil }
+func fail(context.Context) error { return errors.New("down") }
+
+// status returns the serving status of the server as a whole and of the
+// test service.
+func status(t *testing.T, server *health.Server) (whole, service healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+
+	res := make([]healthpb.HealthCheckResponse_ServingStatus, 2)
+	for i, name := range []string{"", "test"} {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+		if err != nil {
+			t.Fatalf("Check(%q): %v", name, err)
+		}
+		res[i] = resp.Status
+	}
+	return res[0], res[1]
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		hard, soft  map[string]Check
+		wantWhole   healthpb.HealthCheckResponse_ServingStatus
+		wantService healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"all pass", map[string]Check{"postgres": pass}, map[string]Check{"redis": pass}, serving, serving},
+		{"soft fails", map[string]Check{"postgres": pass}, map[string]Check{"redis": fail}, serving, notServing},
+		{"hard fails", map[string]Check{"postgres": fail}, map[string]Check{"redis": pass}, notServing, notServing},
+		{"no hard dependencies", nil, map[string]Check{"rating": fail}, serving, notServing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := health.NewServer()
+			New(server, "test", tt.hard, tt.soft, zap.NewNop()).check(context.Background(), nil)
+
+			whole, service := status(t, server)
+			if whole != tt.wantWhole {
+				t.Errorf("server: got %s, want %s", whole, tt.wantWhole)
+			}
+			if service != tt.wantService {
+				t.Errorf("service: got %s, want %s", service, tt.wantService)
+			}
+		})
+	}
+}
+
+func TestCheckFollowsSoftDependency(t *testing.T) {
+	redis := pass
+	server := health.NewServer()
+	c := New(server, "test", map[string]Check{"postgres": pass}, map[string]Check{
+		"redis": func(ctx context.Context) error { return redis(ctx) },
+	}, zap.NewNop())
+
+	var failing []string
+	for _, step := range []struct {
+		check       Check
+		wantService healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{pass, serving},
+		{fail, notServing},
+		{pass, serving},
+	} {
+		redis = step.check
+		failing = c.check(context.Background(), failing)
+
+		whole, service := status(t, server)
+		if whole != serving {
+			t.Errorf("server: got %s, want %s", whole, serving)
+		}
+		if service != step.wantService {
+			t.Errorf("service: got %s, want %s", service, step.wantService)
+		}
+	}
+}
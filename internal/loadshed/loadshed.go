@@ -0,0 +1,139 @@
+// Package loadshed limits the number of calls a gRPC server handles at once
+// and adapts the limit to how fast calls complete, so that excess calls are
+// rejected up front instead of queueing behind slow ones.
+package loadshed
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/metrics"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// overloadedReason is the reason in the ErrorInfo detail of ErrOverloaded.
+const overloadedReason = "OVERLOADED"
+
+// ErrOverloaded is returned for calls rejected by the limit. Clients retry
+// them on another instance. It carries an ErrorInfo detail, so that clients
+// can tell it apart from other Unavailable errors with IsOverloaded.
+var ErrOverloaded = func() error {
+	s, _ := status.New(codes.Unavailable, "server is overloaded").WithDetails(&errdetails.ErrorInfo{Reason: overloadedReason, Domain: "loadshed"})
+	return s.Err()
+}()
+
+// IsOverloaded reports whether a call was rejected with ErrOverloaded.
+func IsOverloaded(err error) bool {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Reason == overloadedReason {
+			return true
+		}
+	}
+	return false
+}
+
+// Config tunes the concurrency limit of a server.
+//
+// The limit grows by about one for each limit's worth of calls completing
+// within LatencyTarget while at least half of it is in use. It is
+// multiplied by BackoffRatio when a call is slower than LatencyTarget or
+// runs out of time, at most once per round of calls.
+type Config struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// LatencyTarget is the latency above which a call is taken as a sign
+	// of overload.
+	LatencyTarget time.Duration
+	BackoffRatio  float64
+}
+
+var DefaultConfig = Config{
+	InitialLimit:  20,
+	MinLimit:      5,
+	MaxLimit:      1000,
+	LatencyTarget: time.Second,
+	BackoffRatio:  0.9,
+}
+
+// UnaryServerInterceptor returns a server interceptor that rejects calls
+// with ErrOverloaded once the concurrency limit is reached. Health checks
+// are never rejected, so an overloaded instance is not taken for a dead one.
+func UnaryServerInterceptor(config Config, m *metrics.ConcurrencyMetrics) grpc.UnaryServerInterceptor {
+	l := &limiter{config: config, metrics: m, limit: float64(config.InitialLimit)}
+	m.SetConcurrencyLimit(config.InitialLimit)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/") {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		inFlight, ok := l.acquire()
+		if !ok {
+			m.IncShedCount(info.FullMethod)
+			return nil, ErrOverloaded
+		}
+
+		resp, err := handler(ctx, req)
+		l.release(start, inFlight, status.Code(err) == codes.DeadlineExceeded)
+		return resp, err
+	}
+}
+
+type limiter struct {
+	config  Config
+	metrics *metrics.ConcurrencyMetrics
+
+	mu          sync.Mutex
+	limit       float64
+	inFlight    int
+	lastBackoff time.Time
+}
+
+// acquire takes a slot for a call and returns the number of calls in
+// flight with it.
+func (l *limiter) acquire() (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		return l.inFlight, false
+	}
+	l.inFlight++
+	l.metrics.SetInFlight(l.inFlight)
+	return l.inFlight, true
+}
+
+// release frees the slot of a call started at start with inFlight calls in
+// flight, and adjusts the limit by how the call went.
+func (l *limiter) release(start time.Time, inFlight int, deadlineExceeded bool) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.metrics.SetInFlight(l.inFlight)
+
+	switch {
+	case deadlineExceeded || now.Sub(start) > l.config.LatencyTarget:
+		// The calls in flight during the last backoff are slow for the same
+		// reason, so they do not back off again.
+		if start.After(l.lastBackoff) {
+			l.limit = max(float64(l.config.MinLimit), l.limit*l.config.BackoffRatio)
+			l.lastBackoff = now
+		}
+	case inFlight*2 >= int(l.limit):
+		// Calls that leave most of the limit unused say nothing about
+		// whether a higher one would hold.
+		l.limit = min(float64(l.config.MaxLimit), l.limit+1/l.limit)
+	}
+	l.metrics.SetConcurrencyLimit(int(l.limit))
+}
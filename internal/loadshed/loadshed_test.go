@@ -0,0 +1,63 @@
+package loadshed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsOverloaded(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"overloaded", ErrOverloaded, true},
+		{"other unavailable", status.Error(codes.Unavailable, "connection refused"), false},
+		{"not a status", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOverloaded(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorSheds(t *testing.T) {
+	config := Config{InitialLimit: 2, MinLimit: 1, MaxLimit: 10, LatencyTarget: time.Second, BackoffRatio: 0.9}
+	intercept := UnaryServerInterceptor(config, metrics.NewConcurrency(prometheus.NewRegistry()))
+	info := &grpc.UnaryServerInfo{FullMethod: "/MovieService/GetMovieDetails"}
+
+	release := make(chan struct{})
+	started := make(chan struct{}, config.InitialLimit)
+	block := func(context.Context, any) (any, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	}
+	for range config.InitialLimit {
+		go intercept(context.Background(), nil, info, block)
+		<-started
+	}
+
+	_, err := intercept(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, nil })
+	if !IsOverloaded(err) {
+		t.Errorf("call past the limit: got %v, want %v", err, ErrOverloaded)
+	}
+
+	health := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	if _, err := intercept(context.Background(), nil, health, func(context.Context, any) (any, error) { return nil, nil }); err != nil {
+		t.Errorf("health check past the limit: %v", err)
+	}
+	close(release)
+}
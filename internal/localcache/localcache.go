@@ -0,0 +1,152 @@
+// Package localcache keeps a bounded in-process copy of Redis entries, so
+// that hot entries are served without a round trip. Instances evict each
+// other's copies through Redis pub/sub when an entry is invalidated.
+package localcache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/ochamekan/ms/pkg/logging"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// entry is a copy of a value as it was in Redis at a point in time.
+type entry[V any] struct {
+	value V
+	at    time.Time
+}
+
+// Cache holds at most size entries, each for at most ttl. Copies of
+// entries invalidated by other instances are evicted as soon as Run hears
+// of it, the ttl bounds how long one could be served after a missed
+// invalidation.
+type Cache[V any] struct {
+	lru *expirable.LRU[string, entry[V]]
+	// invalidated holds when each key was last invalidated, so that values
+	// read from Redis before that are not added back.
+	invalidated *expirable.LRU[string, time.Time]
+	// purgedAt is when all entries were last evicted.
+	purgedAt time.Time
+	// mu orders additions with evictions.
+	mu sync.Mutex
+
+	client *redis.Client
+	// origin tells this instance's invalidations apart from the others'.
+	origin  string
+	channel string
+	logger  *zap.Logger
+}
+
+// New creates a cache whose invalidations are published on the given Redis
+// channel.
+func New[V any](client *redis.Client, channel string, size int, ttl time.Duration, logger *zap.Logger) *Cache[V] {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return &Cache[V]{
+		lru:         expirable.NewLRU[string, entry[V]](size, nil, ttl),
+		invalidated: expirable.NewLRU[string, time.Time](size, nil, ttl),
+		client:      client,
+		origin:      hex.EncodeToString(b),
+		channel:     channel,
+		logger:      logger.With(zap.String(logging.FieldComponent, "local cache"), zap.String("channel", channel)),
+	}
+}
+
+func (c *Cache[V]) Get(key string) (V, bool) {
+	e, ok := c.lru.Get(key)
+	return e.value, ok
+}
+
+// Add keeps a copy of v, which was read from or written to Redis at at.
+// It is ignored if the key was invalidated since, or if a copy of a later
+// value is kept.
+func (c *Cache[V]) Add(key string, v V, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !at.After(c.purgedAt) {
+		return
+	}
+	if t, ok := c.invalidated.Peek(key); ok && !at.After(t) {
+		return
+	}
+	if e, ok := c.lru.Peek(key); ok && e.at.After(at) {
+		return
+	}
+	c.lru.Add(key, entry[V]{value: v, at: at})
+}
+
+// Invalidate evicts the given keys here and on every other instance.
+func (c *Cache[V]) Invalidate(ctx context.Context, keys ...string) error {
+	c.evict(keys...)
+
+	_, err := c.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, k := range keys {
+			p.Publish(ctx, c.channel, c.origin+" "+k)
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *Cache[V]) evict(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, k := range keys {
+		c.lru.Remove(k)
+		c.invalidated.Add(k, now)
+	}
+}
+
+func (c *Cache[V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Purge()
+	c.purgedAt = time.Now()
+}
+
+// Run evicts the keys invalidated by other instances until ctx is done.
+// Invalidations published while the subscription is down are lost, so all
+// entries are evicted whenever it is established again.
+func (c *Cache[V]) Run(ctx context.Context) {
+	sub := c.client.Subscribe(ctx, c.channel)
+	defer sub.Close()
+
+	ch := sub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			switch m := msg.(type) {
+			case *redis.Subscription:
+				if m.Kind == "subscribe" {
+					c.logger.Debug("Subscribed to invalidations, evicting all entries")
+					c.purge()
+				}
+			case *redis.Message:
+				// This instance evicted its own copy when it published
+				// the invalidation, and may have added a later one since.
+				origin, key, _ := strings.Cut(m.Payload, " ")
+				if origin != c.origin {
+					c.evict(key)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package localcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const channel = "test:invalidate"
+
+func newCache(t *testing.T, m *miniredis.Miniredis) *Cache[int] {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New[int](client, channel, 10, time.Minute, zap.NewNop())
+}
+
+// waitFor fails the test unless cond holds within a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+	}
+}
+
+func TestAddIgnoresOlderValues(t *testing.T) {
+	c := newCache(t, miniredis.RunT(t))
+	start := time.Now()
+
+	c.Add("a", 2, start.Add(time.Millisecond))
+	c.Add("a", 1, start)
+	if v, _ := c.Get("a"); v != 2 {
+		t.Errorf("got %d, want the later value 2", v)
+	}
+
+	if err := c.Invalidate(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	c.Add("a", 3, start)
+	if _, ok := c.Get("a"); ok {
+		t.Error("value read before the invalidation was added back")
+	}
+
+	c.Add("a", 4, time.Now())
+	if v, _ := c.Get("a"); v != 4 {
+		t.Errorf("got %d, want the value read after the invalidation 4", v)
+	}
+}
+
+func TestInvalidateEvictsOtherInstances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := miniredis.RunT(t)
+	self, other := newCache(t, m), newCache(t, m)
+	go self.Run(ctx)
+	go other.Run(ctx)
+	waitFor(t, func() bool { return m.PubSubNumSub(channel)[channel] == 2 })
+
+	other.Add("a", 1, time.Now())
+	if err := self.Invalidate(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	self.Add("a", 2, time.Now())
+
+	waitFor(t, func() bool {
+		_, ok := other.Get("a")
+		return !ok
+	})
+	if v, ok := self.Get("a"); !ok || v != 2 {
+		t.Errorf("got %d, %v, want the copy added after invalidating 2, true", v, ok)
+	}
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/consul"
+	"github.com/ochamekan/ms/pkg/discovery"
+	"github.com/ochamekan/ms/pkg/discovery/dns"
+	"github.com/ochamekan/ms/pkg/discovery/file"
+	"github.com/ochamekan/ms/pkg/discovery/memory"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultConsulAddr = "discovery:8500"
+	defaultFile       = "registry.yaml"
+	// memoryTTL mirrors the TTL of the Consul health check.
+	memoryTTL = 5 * time.Second
+)
+
+// New creates the discovery.Registry selected by the REGISTRY environment
+// variable:
+//
+//   - consul (default): Consul agent at CONSUL_ADDR.
+//   - dns: SRV records of `_grpc._tcp.<service>.<DNS_DOMAIN>`, queried at
+//     DNS_SERVER or the system resolver.
+//   - file: instances declared in the YAML or JSON file at REGISTRY_FILE.
+//   - memory: in-process registry, only useful when all services run in one process.
+//
+// The returned function releases the registry's resources.
+func New(logger *zap.Logger) (discovery.Registry, func(), error) {
+	switch kind := cmp.Or(os.Getenv("REGISTRY"), "consul"); kind {
+	case "consul":
+		r, err := consul.NewRegistry(cmp.Or(os.Getenv("CONSUL_ADDR"), defaultConsulAddr))
+		if err != nil {
+			return nil, nil, err
+		}
+		return r, r.Close, nil
+	case "dns":
+		r, err := dns.NewRegistry(os.Getenv("DNS_SERVER"), os.Getenv("DNS_DOMAIN"))
+		if err != nil {
+			return nil, nil, err
+		}
+		return r, func() {}, nil
+	case "file":
+		r, err := file.NewRegistry(cmp.Or(os.Getenv("REGISTRY_FILE"), defaultFile), logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return r, func() { r.Close() }, nil
+	case "memory":
+		return memory.NewRegistry(memoryTTL), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown registry %q, expected consul, dns, file or memory", kind)
+	}
+}
+
+// Instance describes this process as an instance of the given service,
+// generating its id and reading tags and metadata from the environment:
+//
+//   - INSTANCE_TAGS: comma separated tags, e.g. "canary".
+//   - INSTANCE_VERSION: build version.
+//   - INSTANCE_ZONE: availability zone.
+//   - INSTANCE_WEIGHT: relative weight, a positive integer.
+func Instance(serviceName string, hostPort string) (discovery.Instance, error) {
+	instance := discovery.Instance{
+		ID:      discovery.GenerateInstanceID(serviceName),
+		Service: serviceName,
+		Address: hostPort,
+		Meta:    make(map[string]string),
+	}
+
+	for t := range strings.SplitSeq(os.Getenv("INSTANCE_TAGS"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			instance.Tags = append(instance.Tags, t)
+		}
+	}
+
+	if v := os.Getenv("INSTANCE_VERSION"); v != "" {
+		instance.Meta[discovery.MetaVersion] = v
+	}
+	if z := os.Getenv("INSTANCE_ZONE"); z != "" {
+		instance.Meta[discovery.MetaZone] = z
+	}
+	if w := os.Getenv("INSTANCE_WEIGHT"); w != "" {
+		if n, err := strconv.Atoi(w); err != nil || n <= 0 {
+			return discovery.Instance{}, fmt.Errorf("INSTANCE_WEIGHT must be a positive integer, got %q", w)
+		}
+		instance.Meta[discovery.MetaWeight] = w
+	}
+
+	return instance, nil
+}
@@ -4,29 +4,43 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/ochamekan/ms/gen"
+	"github.com/ochamekan/ms/internal/healthcheck"
+	"github.com/ochamekan/ms/internal/loadshed"
+	"github.com/ochamekan/ms/internal/registry"
 	"github.com/ochamekan/ms/metadataservice/internal/controller/metadata"
 	grpchandler "github.com/ochamekan/ms/metadataservice/internal/handler/grpc"
 	"github.com/ochamekan/ms/metadataservice/internal/repository/cache"
 	"github.com/ochamekan/ms/metadataservice/internal/repository/postgres"
-	"github.com/ochamekan/ms/pkg/consul"
 	"github.com/ochamekan/ms/pkg/discovery"
 	"github.com/ochamekan/ms/pkg/logging"
+	"github.com/ochamekan/ms/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 const (
 	serviceName = "metadata"
 	port        = 8081
+	metricsPort = 9101
+
+	// latencyTarget is the latency above which calls are taken as a sign of
+	// overload. Metadata is read from Redis or Postgres in a few milliseconds.
+	latencyTarget = 250 * time.Millisecond
 )
 
 func main() {
@@ -40,41 +54,50 @@ func main() {
 		logger.Fatal("Error loading .env file", zap.Error(err))
 	}
 
-	registry, err := consul.NewRegistry("discovery:8500")
+	reg := prometheus.NewRegistry()
+	concurrencyMetrics := metrics.NewConcurrency(reg)
+
+	go func() {
+		http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", metricsPort), nil); err != nil {
+			logger.Error("Failed to serve metrics", zap.Error(err))
+		}
+	}()
+
+	instance, err := registry.Instance(serviceName, fmt.Sprintf("metadata:%d", port))
 	if err != nil {
-		logger.Fatal("Failed to create consul registry", zap.Error(err))
+		logger.Fatal("Failed to describe instance", zap.Error(err))
 	}
 
-	instanceID := discovery.GenerateInstanceID(serviceName)
+	serviceRegistry, closeRegistry, err := registry.New(logger)
+	if err != nil {
+		logger.Fatal("Failed to create service registry", zap.Error(err))
+	}
+	defer closeRegistry()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if err := registry.Register(ctx, instanceID, serviceName, fmt.Sprintf("metadata:%d", port)); err != nil {
-		logger.Fatal("Failed to register instance", zap.Error(err))
-	}
-
-	go func() {
-		for {
-			if err := registry.ReportHealthyState(instanceID, serviceName); err != nil {
-				logger.Error("Failed to report healthy state", zap.Error(err))
-			}
-			time.Sleep(1 * time.Second)
-		}
-
-	}()
-	defer registry.Deregister(ctx, instanceID, serviceName)
-
 	repo, closer, err := postgres.New()
 	if err != nil {
 		logger.Fatal("Failed to initialize postgresql database", zap.Error(err))
 	}
 	defer closer()
 
-	cache, err := cache.New(serviceName)
+	cacheTTL, err := durationEnv("METADATA_REDIS_TTL", cache.DefaultTTL)
+	if err != nil {
+		logger.Fatal("Failed to configure redis cache", zap.Error(err))
+	}
+	cacheTTLJitter, err := fractionEnv("METADATA_REDIS_TTL_JITTER", cache.DefaultTTLJitter)
+	if err != nil {
+		logger.Fatal("Failed to configure redis cache", zap.Error(err))
+	}
+
+	cache, err := cache.New(serviceName, cacheTTL, cacheTTLJitter, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize redis database", zap.Error(err))
 	}
+	go cache.Run(ctx)
 
 	ctrl := metadata.New(repo, cache, logger)
 	h := grpchandler.New(ctrl, logger)
@@ -84,16 +107,37 @@ func main() {
 		logger.Fatal("Failed to listen", zap.Error(err))
 	}
 
-	srv := grpc.NewServer()
+	concurrencyConfig := loadshed.DefaultConfig
+	concurrencyConfig.LatencyTarget = latencyTarget
+	srv := grpc.NewServer(grpc.UnaryInterceptor(loadshed.UnaryServerInterceptor(concurrencyConfig, concurrencyMetrics)))
 	reflection.Register(srv)
 
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	go healthcheck.New(healthSrv, gen.MetadataService_ServiceDesc.ServiceName, map[string]healthcheck.Check{
+		"postgres": repo.Ping,
+	}, map[string]healthcheck.Check{
+		"redis": cache.Ping,
+	}, logger).Run(ctx)
+
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGINT)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	// Registered only once the server is ready to accept calls.
+	registration, err := discovery.Register(ctx, serviceRegistry, instance, logger)
+	if err != nil {
+		logger.Fatal("Failed to register instance", zap.Error(err))
+	}
+
 	var wg sync.WaitGroup
 
 	wg.Go(func() {
 		s := <-sigChan
 		logger.Info("Received signal, attempting graceful shutdown", zap.Stringer("signal", s))
+		if err := registration.Deregister(context.Background()); err != nil {
+			logger.Error("Failed to deregister instance", zap.Error(err))
+		}
+		healthSrv.Shutdown()
 		cancel()
 		srv.GracefulStop()
 		logger.Info("Gracefully stopped the gRPC server for metadata service")
@@ -101,8 +145,39 @@ func main() {
 
 	gen.RegisterMetadataServiceServer(srv, h)
 	if err := srv.Serve(lis); err != nil {
+		registration.Deregister(context.Background())
 		logger.Fatal("Failed to serve", zap.Error(err))
 	}
 
 	wg.Wait()
 }
+
+// durationEnv returns the duration set in the given environment variable,
+// or def if it is unset.
+func durationEnv(env string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(env)
+	if v == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", env, v)
+	}
+	return d, nil
+}
+
+// fractionEnv returns the fraction in [0, 1) set in the given environment
+// variable, or def if it is unset.
+func fractionEnv(env string, def float64) (float64, error) {
+	v := os.Getenv(env)
+	if v == "" {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f >= 1 {
+		return 0, fmt.Errorf("%s must be in [0, 1), got %q", env, v)
+	}
+	return f, nil
+}
@@ -15,15 +15,26 @@ var ErrNotFound = errors.New("not found")
 type metadataRepository interface {
 	Get(ctx context.Context, id int) (*model.Metadata, error)
 	Put(ctx context.Context, metadata *model.Metadata) error
+	BatchGet(ctx context.Context, ids []int) ([]*model.Metadata, error)
+}
+
+type metadataStore interface {
+	metadataRepository
+	List(ctx context.Context, limit, offset int, order model.Order, directors []string) ([]*model.Metadata, error)
+}
+
+type metadataCache interface {
+	metadataRepository
+	Delete(ctx context.Context, ids ...int) error
 }
 
 type Controller struct {
-	repo   metadataRepository
-	cache  metadataRepository
+	repo   metadataStore
+	cache  metadataCache
 	logger *zap.Logger
 }
 
-func New(repo metadataRepository, cache metadataRepository, logger *zap.Logger) *Controller {
+func New(repo metadataStore, cache metadataCache, logger *zap.Logger) *Controller {
 	return &Controller{repo, cache, logger.With(zap.String(logging.FieldComponent, "metadata controller"))}
 }
 
@@ -48,6 +59,67 @@ func (c *Controller) GetMetadata(ctx context.Context, id int) (*model.Metadata,
 	return res, err
 }
 
+// PutMovieData stores a movie and drops any cached metadata under its ID,
+// e.g. one left over from before the IDs were reset.
 func (c *Controller) PutMovieData(ctx context.Context, metadata *model.Metadata) error {
-	return c.repo.Put(ctx, metadata)
+	logger := c.logger.With(zap.String(logging.FieldEndpoint, "PutMovieData"))
+	if err := c.repo.Put(ctx, metadata); err != nil {
+		return err
+	}
+
+	if err := c.cache.Delete(ctx, metadata.ID); err != nil {
+		logger.Error("Failed to invalidate redis cache", zap.Error(err))
+	}
+	return nil
+}
+
+// BatchGetMetadata returns metadata for the given ids, reading through the
+// cache. Ids that do not exist are omitted from the result.
+func (c *Controller) BatchGetMetadata(ctx context.Context, ids []int) ([]*model.Metadata, error) {
+	logger := c.logger.With(zap.String(logging.FieldEndpoint, "BatchGetMetadata"))
+	found := make(map[int]*model.Metadata, len(ids))
+
+	cached, err := c.cache.BatchGet(ctx, ids)
+	if err != nil {
+		logger.Warn("Failed to read redis cache", zap.Error(err))
+	}
+	for _, m := range cached {
+		found[m.ID] = m
+	}
+
+	var missing []int
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := c.repo.BatchGet(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range fetched {
+			found[m.ID] = m
+			if err := c.cache.Put(ctx, m); err != nil {
+				logger.Error("Failed to update redis cache", zap.Error(err))
+			}
+		}
+	}
+
+	res := make([]*model.Metadata, 0, len(found))
+	for _, id := range ids {
+		if m, ok := found[id]; ok {
+			res = append(res, m)
+			delete(found, id)
+		}
+	}
+
+	return res, nil
+}
+
+// ListMetadata returns a page of metadata in the given order, optionally
+// restricted to movies by the given directors.
+func (c *Controller) ListMetadata(ctx context.Context, limit, offset int, order model.Order, directors []string) ([]*model.Metadata, error) {
+	return c.repo.List(ctx, limit, offset, order, directors)
 }
@@ -13,6 +13,9 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// maxBatchSize caps the number of records a single batch or list call may return.
+const maxBatchSize = 100
+
 type Handler struct {
 	gen.UnimplementedMetadataServiceServer
 	ctrl   *metadata.Controller
@@ -52,7 +55,7 @@ func (h *Handler) PutMetadata(ctx context.Context, req *gen.PutMetadataRequest)
 	}
 
 	logger.Info("Putting metadata")
-	err := h.ctrl.PutMovieData(ctx, &model.Metadata{Title: req.Title, Description: req.Description, Year: int(req.Year), Director: req.Director})
+	err := h.ctrl.PutMovieData(ctx, &model.Metadata{Title: req.Title, Description: req.Description, Year: int(req.Year), Director: req.Director, Tags: req.Tags})
 	if err != nil {
 		logger.Error("Failed to put metadata", zap.Error(err))
 		return nil, err
@@ -61,3 +64,58 @@ func (h *Handler) PutMetadata(ctx context.Context, req *gen.PutMetadataRequest)
 	logger.Info("Metadata successfully added")
 	return &gen.PutMetadataResponse{}, nil
 }
+
+func (h *Handler) BatchGetMetadata(ctx context.Context, req *gen.BatchGetMetadataRequest) (*gen.BatchGetMetadataResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "BatchGetMetadata"))
+	if req == nil || len(req.Ids) == 0 || len(req.Ids) > maxBatchSize {
+		logger.Warn("nil request or incorrect number of ids")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or number of ids not in [1, %d]", maxBatchSize)
+	}
+
+	ids := make([]int, len(req.Ids))
+	for i, id := range req.Ids {
+		if id <= 0 {
+			logger.Warn("incorrect movie id", zap.Int32("id", id))
+			return nil, status.Errorf(codes.InvalidArgument, "incorrect movie id %d", id)
+		}
+		ids[i] = int(id)
+	}
+
+	logger.Info("Getting metadata by ids")
+	ms, err := h.ctrl.BatchGetMetadata(ctx, ids)
+	if err != nil {
+		logger.Error("Failed to get metadata", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &gen.BatchGetMetadataResponse{Metadata: make([]*gen.Metadata, len(ms))}
+	for i, m := range ms {
+		res.Metadata[i] = model.MetadataToProto(m)
+	}
+
+	logger.Info("Successfully retrieved metadata")
+	return res, nil
+}
+
+func (h *Handler) ListMetadata(ctx context.Context, req *gen.ListMetadataRequest) (*gen.ListMetadataResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "ListMetadata"))
+	if req == nil || req.Limit <= 0 || req.Limit > maxBatchSize || req.Offset < 0 {
+		logger.Warn("nil request or incorrect limit or offset")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or limit not in [1, %d] or negative offset", maxBatchSize)
+	}
+
+	logger.Info("Listing metadata")
+	ms, err := h.ctrl.ListMetadata(ctx, int(req.Limit), int(req.Offset), model.OrderFromProto(req.Order), req.Directors)
+	if err != nil {
+		logger.Error("Failed to list metadata", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &gen.ListMetadataResponse{Metadata: make([]*gen.Metadata, len(ms))}
+	for i, m := range ms {
+		res.Metadata[i] = model.MetadataToProto(m)
+	}
+
+	logger.Info("Successfully listed metadata")
+	return res, nil
+}
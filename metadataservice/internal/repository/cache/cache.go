@@ -4,18 +4,48 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"os"
+	"slices"
+	"time"
 
+	"github.com/ochamekan/ms/internal/localcache"
 	"github.com/ochamekan/ms/metadataservice/pkg/model"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 )
 
+// schemaVersion is part of every key, so that a deployment whose
+// model.Metadata encodes differently uses keys of its own. Version 1
+// replaced the unversioned keys, which never expired.
+const schemaVersion = 1
+
+const (
+	DefaultTTL = 1 * time.Hour
+	// DefaultTTLJitter spreads expiry over ±10% of the TTL, so entries
+	// cached together are not all refetched together.
+	DefaultTTLJitter = 0.1
+
+	// Metadata only changes when a movie is edited, which evicts it on
+	// every instance, so in-process copies are kept for minutes.
+	localSize = 10000
+	localTTL  = 5 * time.Minute
+)
+
+// Cache keeps metadata in Redis, and copies of it in process.
 type Cache struct {
 	client *redis.Client
-	name   string
+	local  *localcache.Cache[model.Metadata]
+	prefix string
+	ttl    time.Duration
+	jitter float64
 }
 
-func New(name string) (*Cache, error) {
+// New creates a cache of metadata under keys prefixed with name. Entries
+// expire after ttl, varied by the jitter fraction of it, which must be in
+// [0, 1). Run must be running for in-process copies to be evicted when
+// other instances delete entries.
+func New(name string, ttl time.Duration, jitter float64, logger *zap.Logger) (*Cache, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     os.Getenv("REDIS_ADDR"),
 		Password: os.Getenv("REDIS_PASSWORD"),
@@ -27,11 +57,36 @@ func New(name string) (*Cache, error) {
 		return nil, err
 	}
 
-	return &Cache{client, name}, nil
+	prefix := fmt.Sprintf("%s:v%d", name, schemaVersion)
+	return &Cache{
+		client: client,
+		local:  localcache.New[model.Metadata](client, prefix+":invalidate", localSize, localTTL, logger),
+		prefix: prefix,
+		ttl:    ttl,
+		jitter: jitter,
+	}, nil
+}
+
+// Run evicts in-process copies of entries deleted by other instances until
+// ctx is done.
+func (c *Cache) Run(ctx context.Context) {
+	c.local.Run(ctx)
+}
+
+// Ping checks that Redis is reachable.
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
 }
 
 func (c *Cache) Get(ctx context.Context, id int) (*model.Metadata, error) {
-	val, err := c.client.Get(ctx, fmt.Sprintf("%s:%d", c.name, id)).Bytes()
+	key := c.key(id)
+	if m, ok := c.local.Get(key); ok {
+		m = clone(m)
+		return &m, nil
+	}
+
+	at := time.Now()
+	val, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +96,7 @@ func (c *Cache) Get(ctx context.Context, id int) (*model.Metadata, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.local.Add(key, clone(m), at)
 
 	return &m, nil
 }
@@ -51,5 +107,89 @@ func (c *Cache) Put(ctx context.Context, metadata *model.Metadata) error {
 		return err
 	}
 
-	return c.client.Set(ctx, fmt.Sprintf("%s:%d", c.name, metadata.ID), json, 0).Err()
+	key := c.key(metadata.ID)
+	if err := c.client.Set(ctx, key, json, c.expiration()).Err(); err != nil {
+		return err
+	}
+	c.local.Add(key, clone(*metadata), time.Now())
+
+	return nil
+}
+
+// Delete invalidates the cached metadata of the given ids, including the
+// copies on every instance.
+func (c *Cache) Delete(ctx context.Context, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = c.key(id)
+	}
+
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	return c.local.Invalidate(ctx, keys...)
+}
+
+// BatchGet returns cached metadata for the given ids, skipping the ones
+// that are not cached.
+func (c *Cache) BatchGet(ctx context.Context, ids []int) ([]*model.Metadata, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var (
+		res  []*model.Metadata
+		keys []string
+	)
+	for _, id := range ids {
+		key := c.key(id)
+		if m, ok := c.local.Get(key); ok {
+			m = clone(m)
+			res = append(res, &m)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	at := time.Now()
+	vals, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var m model.Metadata
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			return nil, err
+		}
+		c.local.Add(keys[i], clone(m), at)
+		res = append(res, &m)
+	}
+
+	return res, nil
+}
+
+// clone copies m so that callers cannot change the in-process copy.
+func clone(m model.Metadata) model.Metadata {
+	m.Tags = slices.Clone(m.Tags)
+	return m
+}
+
+func (c *Cache) key(id int) string {
+	return fmt.Sprintf("%s:%d", c.prefix, id)
+}
+
+func (c *Cache) expiration() time.Duration {
+	return time.Duration(float64(c.ttl) * (1 + c.jitter*(2*rand.Float64()-1)))
 }
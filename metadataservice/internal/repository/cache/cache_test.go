@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ochamekan/ms/metadataservice/pkg/model"
+	"go.uber.org/zap"
+)
+
+func TestLocalCopiesAreCloned(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("REDIS_ADDR", miniredis.RunT(t).Addr())
+	c, err := New("metadata", DefaultTTL, DefaultTTLJitter, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &model.Metadata{ID: 1, Tags: []string{"drama"}}
+	if err := c.Put(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	m.Tags[0] = "changed"
+
+	got, err := c.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Tags[0] = "changed too"
+
+	batch, err := c.BatchGet(ctx, []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch[0].Tags[0] = "changed again"
+
+	if got, _ := c.Get(ctx, 1); got.Tags[0] != "drama" {
+		t.Errorf("got tag %q, want %q", got.Tags[0], "drama")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"os"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ochamekan/ms/metadataservice/internal/repository"
 	"github.com/ochamekan/ms/metadataservice/pkg/model"
@@ -27,11 +28,16 @@ func New() (*Repository, func(), error) {
 	return &Repository{dbpool}, closer, nil
 }
 
+// Ping checks that the database is reachable.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx)
+}
+
 func (r *Repository) Get(ctx context.Context, id int) (*model.Metadata, error) {
 	var m model.Metadata
 
-	row := r.db.QueryRow(ctx, "SELECT * FROM movies WHERE id = $1", id)
-	err := row.Scan(&m.ID, &m.Title, &m.Year, &m.Description, &m.Director)
+	row := r.db.QueryRow(ctx, "SELECT id, title, year, description, director, tags FROM movies WHERE id = $1", id)
+	err := row.Scan(&m.ID, &m.Title, &m.Year, &m.Description, &m.Director, &m.Tags)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, repository.ErrNotFound
@@ -42,8 +48,57 @@ func (r *Repository) Get(ctx context.Context, id int) (*model.Metadata, error) {
 	return &m, nil
 }
 
+// Put inserts a movie and sets the ID it was given.
 func (r *Repository) Put(ctx context.Context, metadata *model.Metadata) error {
-	_, err := r.db.Exec(ctx, "INSERT INTO movies (title, year, description, director) VALUES ($1, $2, $3, $4)", metadata.Title, metadata.Year, metadata.Description, metadata.Director)
-	return err
+	tags := metadata.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return r.db.QueryRow(ctx, "INSERT INTO movies (title, year, description, director, tags) VALUES ($1, $2, $3, $4, $5) RETURNING id", metadata.Title, metadata.Year, metadata.Description, metadata.Director, tags).Scan(&metadata.ID)
+}
+
+func (r *Repository) BatchGet(ctx context.Context, ids []int) ([]*model.Metadata, error) {
+	rows, err := r.db.Query(ctx, "SELECT id, title, year, description, director, tags FROM movies WHERE id = ANY($1)", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMetadata(rows)
+}
+
+func (r *Repository) List(ctx context.Context, limit, offset int, order model.Order, directors []string) ([]*model.Metadata, error) {
+	where, args := "", []any{limit, offset}
+	if len(directors) > 0 {
+		where, args = "WHERE director = ANY($3) ", append(args, directors)
+	}
+
+	orderBy := "id"
+	switch order {
+	case model.OrderYearDesc:
+		orderBy = "year DESC, id"
+	case model.OrderYearAsc:
+		orderBy = "year, id"
+	}
+
+	rows, err := r.db.Query(ctx, "SELECT id, title, year, description, director, tags FROM movies "+where+"ORDER BY "+orderBy+" LIMIT $1 OFFSET $2", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMetadata(rows)
+}
+
+func scanMetadata(rows pgx.Rows) ([]*model.Metadata, error) {
+	var res []*model.Metadata
+	for rows.Next() {
+		var m model.Metadata
+		if err := rows.Scan(&m.ID, &m.Title, &m.Year, &m.Description, &m.Director, &m.Tags); err != nil {
+			return nil, err
+		}
+		res = append(res, &m)
+	}
 
+	return res, rows.Err()
 }
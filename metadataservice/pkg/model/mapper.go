@@ -10,6 +10,7 @@ func MetadataToProto(m *Metadata) *gen.Metadata {
 		Year:        int32(m.Year),
 		Description: m.Description,
 		Director:    m.Director,
+		Tags:        m.Tags,
 	}
 }
 
@@ -22,5 +23,30 @@ func MetadataFromProto(m *gen.Metadata) *Metadata {
 		Year:        int(m.Year),
 		Description: m.Description,
 		Director:    m.Director,
+		Tags:        m.Tags,
+	}
+}
+
+// OrderFromProto converts a generated proto order into an Order.
+func OrderFromProto(o gen.MetadataOrder) Order {
+	switch o {
+	case gen.MetadataOrder_METADATA_ORDER_YEAR_DESC:
+		return OrderYearDesc
+	case gen.MetadataOrder_METADATA_ORDER_YEAR_ASC:
+		return OrderYearAsc
+	default:
+		return OrderID
+	}
+}
+
+// OrderToProto converts an Order into its generated proto counterpart.
+func OrderToProto(o Order) gen.MetadataOrder {
+	switch o {
+	case OrderYearDesc:
+		return gen.MetadataOrder_METADATA_ORDER_YEAR_DESC
+	case OrderYearAsc:
+		return gen.MetadataOrder_METADATA_ORDER_YEAR_ASC
+	default:
+		return gen.MetadataOrder_METADATA_ORDER_ID
 	}
 }
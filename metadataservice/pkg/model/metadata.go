@@ -1,9 +1,19 @@
 package model
 
 type Metadata struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Year        int    `json:"year"`
-	Description string `json:"description"`
-	Director    string `json:"director"`
+	ID          int      `json:"id"`
+	Title       string   `json:"title"`
+	Year        int      `json:"year"`
+	Description string   `json:"description"`
+	Director    string   `json:"director"`
+	Tags        []string `json:"tags"`
 }
+
+// Order defines how listed metadata is sorted.
+type Order int
+
+const (
+	OrderID Order = iota
+	OrderYearDesc
+	OrderYearAsc
+)
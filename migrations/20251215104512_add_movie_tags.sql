@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS tags text[] NOT NULL DEFAULT '{}';
+
+UPDATE movies SET tags = t.tags FROM (VALUES
+('Citizen Kane', ARRAY['drama', 'mystery', 'media', 'biography']),
+('The Godfather', ARRAY['crime', 'drama', 'mafia', 'family']),
+('Vertigo', ARRAY['thriller', 'mystery', 'obsession', 'romance']),
+('2001: A Space Odyssey', ARRAY['science fiction', 'space', 'philosophical']),
+('Tokyo Story', ARRAY['drama', 'family', 'japan', 'aging']),
+('The Rules of the Game', ARRAY['comedy', 'drama', 'satire', 'aristocracy']),
+('Seven Samurai', ARRAY['action', 'adventure', 'samurai', 'japan', 'epic']),
+('8½', ARRAY['drama', 'fantasy', 'filmmaking', 'surreal']),
+('Singin'' in the Rain', ARRAY['musical', 'comedy', 'romance', 'filmmaking']),
+('Bicycle Thieves', ARRAY['drama', 'neorealism', 'poverty', 'family']),
+('The Godfather Part II', ARRAY['crime', 'drama', 'mafia', 'family', 'epic']),
+('Casablanca', ARRAY['drama', 'romance', 'war']),
+('Pulp Fiction', ARRAY['crime', 'drama', 'comedy', 'nonlinear']),
+('Lawrence of Arabia', ARRAY['adventure', 'biography', 'war', 'epic', 'desert']),
+('Sunrise: A Song of Two Humans', ARRAY['drama', 'romance', 'silent']),
+('The Searchers', ARRAY['western', 'adventure', 'drama', 'revenge']),
+('Apocalypse Now', ARRAY['war', 'drama', 'vietnam', 'madness']),
+('Taxi Driver', ARRAY['crime', 'drama', 'vietnam', 'loneliness', 'new york']),
+('Rashomon', ARRAY['crime', 'drama', 'mystery', 'japan', 'nonlinear']),
+('Sunset Boulevard', ARRAY['drama', 'noir', 'filmmaking', 'obsession']),
+('The 400 Blows', ARRAY['drama', 'coming of age', 'new wave', 'crime']),
+('Some Like It Hot', ARRAY['comedy', 'romance', 'crime', 'music']),
+('Psycho', ARRAY['horror', 'thriller', 'mystery', 'crime']),
+('Raging Bull', ARRAY['drama', 'biography', 'sport', 'boxing']),
+('La Dolce Vita', ARRAY['drama', 'comedy', 'rome', 'media']),
+('City Lights', ARRAY['comedy', 'romance', 'silent', 'drama']),
+('Modern Times', ARRAY['comedy', 'silent', 'satire', 'industry']),
+('Breathless', ARRAY['crime', 'drama', 'romance', 'new wave']),
+('Metropolis', ARRAY['science fiction', 'drama', 'silent', 'dystopia', 'industry']),
+('The Passion of Joan of Arc', ARRAY['drama', 'biography', 'history', 'silent', 'faith']),
+('Andrei Rublev', ARRAY['drama', 'biography', 'history', 'faith', 'epic']),
+('Persona', ARRAY['drama', 'psychological', 'identity']),
+('Wild Strawberries', ARRAY['drama', 'aging', 'memory', 'road']),
+('The Seventh Seal', ARRAY['drama', 'fantasy', 'faith', 'death', 'history']),
+('L''Avventura', ARRAY['drama', 'mystery', 'romance', 'alienation']),
+('Au Hasard Balthazar', ARRAY['drama', 'faith', 'animals']),
+('Stalker', ARRAY['science fiction', 'drama', 'philosophical', 'faith']),
+('Mirror', ARRAY['drama', 'memory', 'history', 'philosophical']),
+('The Night of the Hunter', ARRAY['thriller', 'crime', 'noir', 'faith']),
+('Goodfellas', ARRAY['crime', 'drama', 'mafia', 'biography'])
+) AS t(title, tags)
+WHERE movies.title = t.title;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE movies DROP COLUMN tags;
+-- +goose StatementEnd
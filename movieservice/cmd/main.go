@@ -1,39 +1,71 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
 	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
-	"github.com/grpc-ecosystem/go-grpc-middleware/ratelimit"
+	"github.com/joho/godotenv"
 	"github.com/ochamekan/ms/gen"
+	"github.com/ochamekan/ms/internal/grpcutil"
+	"github.com/ochamekan/ms/internal/healthcheck"
+	"github.com/ochamekan/ms/internal/loadshed"
+	"github.com/ochamekan/ms/internal/registry"
 	"github.com/ochamekan/ms/movieservice/internal/controller/movie"
+	"github.com/ochamekan/ms/movieservice/internal/gateway"
 	metadatagateway "github.com/ochamekan/ms/movieservice/internal/gateway/metadata/grpc"
 	ratinggateway "github.com/ochamekan/ms/movieservice/internal/gateway/rating/grpc"
+	graphqlhandler "github.com/ochamekan/ms/movieservice/internal/handler/graphql"
 	grpchandler "github.com/ochamekan/ms/movieservice/internal/handler/grpc"
-	"github.com/ochamekan/ms/pkg/consul"
+	httphandler "github.com/ochamekan/ms/movieservice/internal/handler/http"
+	"github.com/ochamekan/ms/movieservice/internal/ratelimit"
 	"github.com/ochamekan/ms/pkg/discovery"
 	"github.com/ochamekan/ms/pkg/logging"
 	"github.com/ochamekan/ms/pkg/metrics"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 const (
 	serviceName = "movie"
 	port        = 8083
+	httpPort    = 8084
 	metricsPort = 9100
+
+	// detailsCacheTTL is how long movie details are served from memory.
+	detailsCacheTTL = 5 * time.Second
+	// resolverRefreshInterval is how often downstream instances are looked up in the registry.
+	resolverRefreshInterval = 5 * time.Second
+	// Default timeouts of each call to the downstream services. The rating
+	// service spends about a second on every read.
+	defaultMetadataTimeout = 500 * time.Millisecond
+	defaultRatingTimeout   = 2 * time.Second
+	// defaultRateLimitFile holds the rate limits per client and method.
+	defaultRateLimitFile = "ratelimit.yaml"
+	// graphqlMethod names GraphQL queries in rate limits.
+	graphqlMethod = "/graphql"
+	// rateLimitRedisTimeout bounds each rate limit check in Redis, after
+	// which the call is limited by this instance alone.
+	rateLimitRedisTimeout = 50 * time.Millisecond
+	// latencyTarget is the latency above which calls are taken as a sign of
+	// overload, a little over the rating timeout.
+	latencyTarget = 3 * time.Second
 )
 
 func main() {
@@ -42,6 +74,10 @@ func main() {
 	logger = logger.With(zap.String(logging.FieldService, serviceName))
 	logger.Info("Starting movie service")
 
+	if err := godotenv.Load(); err != nil {
+		logger.Warn("No .env file loaded, using process environment", zap.Error(err))
+	}
+
 	srvMetrics := grpcprom.NewServerMetrics(
 		grpcprom.WithServerHandlingTimeHistogram(
 			grpcprom.WithHistogramBuckets([]float64{0.001, 0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120}),
@@ -51,41 +87,83 @@ func main() {
 	reg := prometheus.NewRegistry()
 	reg.MustRegister(srvMetrics)
 
+	concurrencyMetrics := metrics.NewConcurrency(reg)
 	metrics := metrics.New(reg)
 
 	go func() {
 		http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
-		http.ListenAndServe(fmt.Sprintf(":%d", metricsPort), nil)
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", metricsPort), nil); err != nil {
+			logger.Error("Failed to serve metrics", zap.Error(err))
+		}
 	}()
 
-	registry, err := consul.NewRegistry("discovery:8500")
+	instance, err := registry.Instance(serviceName, fmt.Sprintf("movie:%d", port))
 	if err != nil {
-		logger.Fatal("Failed to create consul registry", zap.Error(err))
+		logger.Fatal("Failed to describe instance", zap.Error(err))
 	}
 
-	instanceID := discovery.GenerateInstanceID(serviceName)
+	serviceRegistry, closeRegistry, err := registry.New(logger)
+	if err != nil {
+		logger.Fatal("Failed to create service registry", zap.Error(err))
+	}
+	defer closeRegistry()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if err := registry.Register(ctx, instanceID, serviceName, fmt.Sprintf("movie:%d", port)); err != nil {
-		logger.Fatal("Failed to register instance", zap.Error(err))
+	builder := grpcutil.NewResolverBuilder(serviceRegistry, resolverRefreshInterval)
+
+	// Creating a movie or a rating twice is worse than failing, so those calls are not retried.
+	retryPolicy := grpcutil.DefaultRetryPolicy
+	retryPolicy.NonIdempotent = []string{gen.MetadataService_PutMetadata_FullMethodName, gen.RatingService_PutRating_FullMethodName}
+
+	metadataPolicy := lbPolicy("METADATA_LB_POLICY", grpcutil.PolicyLeastRequest)
+	// Rating calls are hashed by movie id so each movie's cached rating stays on one instance.
+	ratingPolicy := lbPolicy("RATING_LB_POLICY", grpcutil.PolicyConsistentHash)
+
+	metadataCalls, err := downstreamCalls("METADATA", metadataPolicy, retryPolicy, defaultMetadataTimeout, []string{gen.MetadataService_GetMetadata_FullMethodName}, metrics)
+	if err != nil {
+		logger.Fatal("Failed to configure metadata service calls", zap.Error(err))
+	}
+	ratingCalls, err := downstreamCalls("RATING", ratingPolicy, retryPolicy, defaultRatingTimeout, []string{gen.RatingService_GetAggregatedRating_FullMethodName}, metrics)
+	if err != nil {
+		logger.Fatal("Failed to configure rating service calls", zap.Error(err))
 	}
 
-	go func() {
-		for {
-			if err := registry.ReportHealthyState(instanceID, serviceName); err != nil {
-				logger.Error("Failed to report healthy state", zap.Error(err))
-			}
-			time.Sleep(1 * time.Second)
-		}
+	metadataConn, err := grpcutil.ServiceConnection(serviceTarget("metadata", "METADATA_INSTANCE_FILTER"), builder, metadataPolicy, metadataCalls)
+	if err != nil {
+		logger.Fatal("Failed to create metadata service connection", zap.Error(err))
+	}
+	defer metadataConn.Close()
 
-	}()
-	defer registry.Deregister(ctx, instanceID, serviceName)
+	ratingConn, err := grpcutil.ServiceConnection(serviceTarget("rating", "RATING_INSTANCE_FILTER"), builder, ratingPolicy, ratingCalls)
+	if err != nil {
+		logger.Fatal("Failed to create rating service connection", zap.Error(err))
+	}
+	defer ratingConn.Close()
 
-	metadataGateway := metadatagateway.New(registry, logger)
-	ratingGateway := ratinggateway.New(registry)
-	ctrl := movie.New(ratingGateway, metadataGateway)
+	metadataBreakerConfig, err := gateway.BreakerConfigFromEnv("METADATA")
+	if err != nil {
+		logger.Fatal("Failed to read metadata circuit breaker config", zap.Error(err))
+	}
+	ratingBreakerConfig, err := gateway.BreakerConfigFromEnv("RATING")
+	if err != nil {
+		logger.Fatal("Failed to read rating circuit breaker config", zap.Error(err))
+	}
+	metadataBreaker := gateway.NewBreaker("metadata", metadataBreakerConfig, logger, metrics)
+	ratingBreaker := gateway.NewBreaker("rating", ratingBreakerConfig, logger, metrics)
+
+	metadataCacheConfig := metadatagateway.DefaultCacheConfig
+	if metadataCacheConfig.SoftTTL, err = durationEnv("METADATA_CACHE_SOFT_TTL", metadataCacheConfig.SoftTTL); err != nil {
+		logger.Fatal("Failed to read metadata cache config", zap.Error(err))
+	}
+	if metadataCacheConfig.MaxStale, err = durationEnv("METADATA_CACHE_MAX_STALE", metadataCacheConfig.MaxStale); err != nil {
+		logger.Fatal("Failed to read metadata cache config", zap.Error(err))
+	}
+
+	metadataGateway := metadatagateway.New(metadataBreaker.Wrap(metadataConn), metadataCacheConfig)
+	ratingGateway := ratinggateway.New(ratingBreaker.Wrap(ratingConn))
+	ctrl := movie.New(ratingGateway, metadataGateway, detailsCacheTTL, metrics)
 
 	h := grpchandler.New(ctrl, logger, metrics)
 
@@ -94,49 +172,182 @@ func main() {
 		logger.Fatal("Failed to listen", zap.Error(err))
 	}
 
-	const limit = 100 // max 100 request per second
-	const burst = 100 // max parallel requests
-	lim := newLimiter(limit, burst)
+	rateLimits, err := ratelimit.LoadConfig(cmp.Or(os.Getenv("RATE_LIMIT_FILE"), defaultRateLimitFile))
+	if err != nil {
+		logger.Fatal("Failed to load rate limits", zap.Error(err))
+	}
+	limiter, closeLimiter, err := rateLimiter(logger)
+	if err != nil {
+		logger.Fatal("Failed to create rate limiter", zap.Error(err))
+	}
+	defer closeLimiter()
 
-	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		srvMetrics.UnaryServerInterceptor(),
-		ratelimit.UnaryServerInterceptor(lim),
-	))
+	concurrencyConfig := loadshed.DefaultConfig
+	concurrencyConfig.LatencyTarget = latencyTarget
+	shedLoad := loadshed.UnaryServerInterceptor(concurrencyConfig, concurrencyMetrics)
+	limitRate := ratelimit.UnaryServerInterceptor(limiter, rateLimits, logger)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(srvMetrics.UnaryServerInterceptor(), shedLoad, limitRate))
 
 	reflection.Register(srv)
 
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	// Cached metadata and placeholder ratings are served while the
+	// downstream services are down, so they only flip the status of the
+	// movie service, not the one instances are routed by.
+	go healthcheck.New(healthSrv, gen.MovieService_ServiceDesc.ServiceName, nil, map[string]healthcheck.Check{
+		"metadata": healthcheck.GRPC(metadataConn),
+		"rating":   healthcheck.GRPC(ratingConn),
+	}, logger).Run(ctx)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	srvMetrics.InitializeMetrics(srv)
 
+	// The REST gateway calls the gRPC server over loopback so that requests
+	// go through the same interceptors as native gRPC clients.
+	loopback, err := grpc.NewClient(fmt.Sprintf("localhost:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		logger.Fatal("Failed to create loopback gRPC client", zap.Error(err))
+	}
+	defer loopback.Close()
+
+	gql, err := graphqlhandler.New(metadataGateway, ratingGateway, logger)
+	if err != nil {
+		logger.Fatal("Failed to create graphql handler", zap.Error(err))
+	}
+
+	rest := httphandler.New(gen.NewMovieServiceClient(loopback), logger)
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", rest.Routes())
+	// GraphQL queries call the downstream services directly instead of
+	// through the gRPC server, so they are shed and limited on their own.
+	mux.Handle("POST /graphql", rest.Intercepted(graphqlMethod, gql, shedLoad, limitRate))
+
+	httpSrv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", httpPort),
+		Handler: mux,
+	}
+
+	go func() {
+		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to serve http", zap.Error(err))
+		}
+	}()
+
+	// Registered only once the server is ready to accept calls.
+	registration, err := discovery.Register(ctx, serviceRegistry, instance, logger)
+	if err != nil {
+		logger.Fatal("Failed to register instance", zap.Error(err))
+	}
+
 	var wg sync.WaitGroup
 
 	wg.Go(func() {
 		s := <-sigChan
 		logger.Info("Received signal, attempting graceful shutdown", zap.Stringer("signal", s))
+		if err := registration.Deregister(context.Background()); err != nil {
+			logger.Error("Failed to deregister instance", zap.Error(err))
+		}
+		healthSrv.Shutdown()
 		cancel()
+		if err := httpSrv.Shutdown(context.Background()); err != nil {
+			logger.Error("Failed to shut down the http server", zap.Error(err))
+		}
 		srv.GracefulStop()
 		logger.Info("Gracefully stopped the gRPC server for movie service")
 	})
 
 	gen.RegisterMovieServiceServer(srv, h)
 	if err := srv.Serve(lis); err != nil {
+		registration.Deregister(context.Background())
 		logger.Fatal("Failed to serve", zap.Error(err))
 	}
 
 	wg.Wait()
 }
 
-// limiter implements *ratelimit.Limiter
-type limiter struct {
-	l *rate.Limiter
+// lbPolicy returns the load balancing policy set in the given environment
+// variable, or def if it is unset.
+func lbPolicy(env string, def grpcutil.Policy) grpcutil.Policy {
+	if p := os.Getenv(env); p != "" {
+		return grpcutil.Policy(p)
+	}
+	return def
 }
 
-func newLimiter(limit int, burst int) *limiter {
-	return &limiter{rate.NewLimiter(rate.Limit(limit), burst)}
+// downstreamCalls returns the interceptors of calls to a downstream
+// service. Failed calls are retried, calls to the hedged methods are hedged
+// if <prefix>_HEDGE_PERCENTILE is set, and every attempt is bounded by
+// <prefix>_TIMEOUT, so a hung instance is given up on in time to try another.
+// Hedging needs a policy that sends the copies of a call to different
+// instances, so it is rejected with round_robin.
+func downstreamCalls(prefix string, lbPolicy grpcutil.Policy, retryPolicy grpcutil.RetryPolicy, defaultTimeout time.Duration, hedged []string, m *metrics.Metrics) (grpc.DialOption, error) {
+	timeout, err := durationEnv(prefix+"_TIMEOUT", defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{grpcutil.RetryInterceptor(retryPolicy)}
+	if v := os.Getenv(prefix + "_HEDGE_PERCENTILE"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p <= 0 || p >= 1 {
+			return nil, fmt.Errorf("%s_HEDGE_PERCENTILE must be in (0, 1), got %q", prefix, v)
+		}
+		if lbPolicy == grpcutil.PolicyRoundRobin {
+			return nil, fmt.Errorf("%s_HEDGE_PERCENTILE cannot be used with the %s load balancing policy", prefix, lbPolicy)
+		}
+		interceptors = append(interceptors, grpcutil.HedgeInterceptor(p, hedged, m))
+	}
+	interceptors = append(interceptors, grpcutil.TimeoutInterceptor(timeout))
+
+	return grpc.WithChainUnaryInterceptor(interceptors...), nil
 }
 
-func (l *limiter) Limit() bool {
-	return !l.l.Allow()
+// rateLimiter returns the limiter set in RATE_LIMITER: "local" keeps the
+// limits of each instance apart, "redis" shares them between all instances
+// through the Redis at REDIS_ADDR.
+func rateLimiter(logger *zap.Logger) (ratelimit.Limiter, func(), error) {
+	switch l := cmp.Or(os.Getenv("RATE_LIMITER"), "local"); l {
+	case "local":
+		return ratelimit.NewLocalLimiter(), func() {}, nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:         os.Getenv("REDIS_ADDR"),
+			Password:     os.Getenv("REDIS_PASSWORD"),
+			DB:           0,
+			DialTimeout:  rateLimitRedisTimeout,
+			ReadTimeout:  rateLimitRedisTimeout,
+			WriteTimeout: rateLimitRedisTimeout,
+			MaxRetries:   -1,
+		})
+		return ratelimit.NewRedisLimiter(client, ratelimit.NewLocalLimiter(), logger), func() { client.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown RATE_LIMITER %q, expected local or redis", l)
+	}
+}
+
+// durationEnv returns the duration set in the given environment variable,
+// or def if it is unset.
+func durationEnv(env string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(env)
+	if v == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", env, v)
+	}
+	return d, nil
+}
+
+// serviceTarget appends the instance filter from env, a query such as
+// "tag=canary&zone=eu-west-1b", to the service name.
+func serviceTarget(serviceName string, env string) string {
+	if f := os.Getenv(env); f != "" {
+		return serviceName + "?" + f
+	}
+	return serviceName
 }
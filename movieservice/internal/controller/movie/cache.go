@@ -0,0 +1,114 @@
+package movie
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCache is a short-lived in-process cache. Expired entries are swept when
+// the cache grows past maxEntries. Values are copied with clone when stored
+// and when returned, so callers never share state with the cache.
+//
+// Every put and delete starts a new generation. A value is put with the
+// generation it was computed from, and is dropped if its key was put or
+// deleted since, so that a slow computation cannot overwrite a newer value or
+// bring back an invalidated one.
+type ttlCache[K comparable, V any] struct {
+	ttl        time.Duration
+	maxEntries int
+	clone      func(V) V
+
+	mu      sync.Mutex
+	entries map[K]ttlEntry[V]
+	gen     uint64
+	// floor is the oldest generation values may still be put from. It is
+	// raised when a deleted entry is swept, or cannot be kept.
+	floor uint64
+}
+
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+	gen       uint64
+	// deleted marks a key invalidated at gen.
+	deleted bool
+}
+
+func newTTLCache[K comparable, V any](ttl time.Duration, maxEntries int, clone func(V) V) *ttlCache[K, V] {
+	return &ttlCache[K, V]{ttl: ttl, maxEntries: maxEntries, clone: clone, entries: make(map[K]ttlEntry[V])}
+}
+
+// generation returns the current generation, to be passed to put with a
+// value computed from now on.
+func (c *ttlCache[K, V]) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *ttlCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.deleted || time.Now().After(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+
+	return c.clone(e.value), true
+}
+
+// put keeps v, computed from generation gen, unless the key was put or
+// deleted since.
+func (c *ttlCache[K, V]) put(key K, v V, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen < c.floor {
+		return
+	}
+	if e, ok := c.entries[key]; ok && e.gen > gen {
+		return
+	}
+
+	now := time.Now()
+	if !c.fits(key, now) {
+		return
+	}
+
+	c.gen++
+	c.entries[key] = ttlEntry[V]{value: c.clone(v), expiresAt: now.Add(c.ttl), gen: c.gen}
+}
+
+func (c *ttlCache[K, V]) delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if !c.fits(key, time.Now()) {
+		// Without room to remember the key, no value computed before now
+		// is kept.
+		c.floor = c.gen
+		return
+	}
+	c.entries[key] = ttlEntry[V]{expiresAt: time.Now().Add(c.ttl), gen: c.gen, deleted: true}
+}
+
+// fits reports whether there is room for key, sweeping expired entries if
+// the cache is full.
+func (c *ttlCache[K, V]) fits(key K, now time.Time) bool {
+	if _, ok := c.entries[key]; ok || len(c.entries) < c.maxEntries {
+		return true
+	}
+
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			if e.deleted {
+				c.floor = max(c.floor, e.gen)
+			}
+			delete(c.entries, k)
+		}
+	}
+	return len(c.entries) < c.maxEntries
+}
@@ -0,0 +1,50 @@
+package movie
+
+import (
+	"testing"
+	"time"
+)
+
+func identity(v int) int { return v }
+
+func TestTTLCacheDropsValuesFromBeforeDelete(t *testing.T) {
+	c := newTTLCache[string](time.Minute, 10, identity)
+
+	gen := c.generation()
+	c.delete("a")
+	c.put("a", 1, gen)
+	if v, ok := c.get("a"); ok {
+		t.Errorf("got %d computed before the delete", v)
+	}
+
+	c.put("a", 2, c.generation())
+	if v, ok := c.get("a"); !ok || v != 2 {
+		t.Errorf("got %d, %v, want 2, true", v, ok)
+	}
+}
+
+func TestTTLCacheKeepsNewerValue(t *testing.T) {
+	c := newTTLCache[string](time.Minute, 10, identity)
+
+	old := c.generation()
+	c.put("a", 2, c.generation())
+	c.put("a", 1, old)
+	if v, _ := c.get("a"); v != 2 {
+		t.Errorf("got %d, want the newer value 2", v)
+	}
+}
+
+func TestTTLCacheDropsValuesFromBeforeSweptDelete(t *testing.T) {
+	c := newTTLCache[string](time.Millisecond, 1, identity)
+
+	gen := c.generation()
+	c.delete("a")
+	time.Sleep(2 * time.Millisecond)
+
+	// Putting another key sweeps the expired delete of "a".
+	c.put("b", 1, c.generation())
+	c.put("a", 1, gen)
+	if _, ok := c.entries["a"]; ok {
+		t.Error("value computed before a swept delete was kept")
+	}
+}
@@ -3,11 +3,26 @@ package movie
 import (
 	"context"
 	"errors"
+	"slices"
+	"strconv"
+	"time"
 
 	metadatamodel "github.com/ochamekan/ms/metadataservice/pkg/model"
 	"github.com/ochamekan/ms/movieservice/internal/gateway"
 	"github.com/ochamekan/ms/movieservice/pkg/model"
+	"github.com/ochamekan/ms/pkg/metrics"
 	ratingmodel "github.com/ochamekan/ms/ratingservice/pkg/model"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// maxCachedDetails bounds the number of movies kept in the details cache.
+	maxCachedDetails = 10000
+	// sharedFetchTimeout bounds a fetch of movie details shared by
+	// concurrent callers, retries included, when the caller that starts it
+	// has no deadline. Each downstream call is bounded by its own timeout as
+	// well.
+	sharedFetchTimeout = 5 * time.Second
 )
 
 var ErrNotFound = errors.New("movie metadata not found")
@@ -15,41 +30,239 @@ var ErrNotFound = errors.New("movie metadata not found")
 type ratingGateway interface {
 	GetAggregatedRating(ctx context.Context, movieID ratingmodel.MovieID) (float64, error)
 	PutRating(ctx context.Context, movieID ratingmodel.MovieID, rating ratingmodel.RatingValue) error
+	BatchGetAggregatedRatings(ctx context.Context, movieIDs []ratingmodel.MovieID) (map[ratingmodel.MovieID]float64, error)
+	ListAggregatedRatings(ctx context.Context, limit, offset int) ([]ratingmodel.AggregatedRating, error)
+	BatchGetRatingDistributions(ctx context.Context, movieIDs []ratingmodel.MovieID) (map[ratingmodel.MovieID]ratingmodel.Distribution, error)
 }
 
 type metadataGateway interface {
-	GetMetadata(ctx context.Context, id int) (*metadatamodel.Metadata, error)
+	GetMetadata(ctx context.Context, id int) (m *metadatamodel.Metadata, stale bool, err error)
 	PutMetadata(ctx context.Context, title, description, director string, year int) error
+	BatchGetMetadata(ctx context.Context, ids []int) ([]*metadatamodel.Metadata, error)
+	ListMetadata(ctx context.Context, limit, offset int, order metadatamodel.Order, directors []string) ([]*metadatamodel.Metadata, error)
 }
 
 type Controller struct {
 	ratingGateway   ratingGateway
 	metadataGateway metadataGateway
+	cache           *ttlCache[int, model.MovieDetails]
+	similarCache    *ttlCache[int, []similarID]
+	group           singleflight.Group
+	metrics         *metrics.Metrics
 }
 
-func New(rg ratingGateway, mg metadataGateway) *Controller {
-	return &Controller{rg, mg}
+// New creates a controller that caches movie details for cacheTTL.
+func New(rg ratingGateway, mg metadataGateway, cacheTTL time.Duration, metrics *metrics.Metrics) *Controller {
+	return &Controller{
+		ratingGateway:   rg,
+		metadataGateway: mg,
+		cache:           newTTLCache[int](cacheTTL, maxCachedDetails, model.MovieDetails.Clone),
+		similarCache:    newTTLCache[int](similarTTL, maxCachedDetails, slices.Clone[[]similarID]),
+		metrics:         metrics,
+	}
 }
 
+// Get returns movie details from the cache, or fetches them downstream.
+// Concurrent requests for the same uncached movie share a single fetch.
 func (c *Controller) Get(ctx context.Context, id int) (*model.MovieDetails, error) {
-	metadata, err := c.metadataGateway.GetMetadata(ctx, id)
+	if d, ok := c.cache.get(id); ok {
+		c.metrics.IncMovieDetailsCacheCount(metrics.CacheHit)
+		return &d, nil
+	}
+
+	leader := false
+	ch := c.group.DoChan(strconv.Itoa(id), func() (any, error) {
+		leader = true
+		// The fetch is shared, so the first caller going away does not end
+		// it, but it keeps that caller's deadline. Callers stop waiting by
+		// their own deadlines.
+		fctx, cancel := sharedContext(ctx)
+		defer cancel()
+
+		gen := c.cache.generation()
+		d, rated, err := c.fetch(fctx, id)
+		// Stale details and placeholder ratings are not kept, so that
+		// fresh ones are served as soon as the metadata or rating service
+		// is back.
+		if err == nil && rated && !d.Stale {
+			c.cache.put(id, *d, gen)
+		}
+		return d, err
+	})
+
+	select {
+	case res := <-ch:
+		if leader {
+			c.metrics.IncMovieDetailsCacheCount(metrics.CacheMiss)
+		} else {
+			c.metrics.IncMovieDetailsCacheCount(metrics.CacheCoalesced)
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// The details are shared with the other callers.
+		d := res.Val.(*model.MovieDetails).Clone()
+		return &d, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sharedContext detaches ctx from its cancellation, keeping its deadline or
+// setting one sharedFetchTimeout away if it has none, so that downstream
+// calls never outlive the inbound one.
+func sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(context.WithoutCancel(ctx), deadline)
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), sharedFetchTimeout)
+}
+
+// fetch returns the details of a movie, and whether its rating is known,
+// i.e. fetched or known not to exist.
+func (c *Controller) fetch(ctx context.Context, id int) (*model.MovieDetails, bool, error) {
+	metadata, stale, err := c.metadataGateway.GetMetadata(ctx, id)
 	if err != nil && errors.Is(err, gateway.ErrNotFound) {
-		return nil, ErrNotFound
+		return nil, false, ErrNotFound
 	} else if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	details := &model.MovieDetails{Metadata: *metadata}
+	details := &model.MovieDetails{Metadata: *metadata, Stale: stale}
 
+	// A movie without ratings, or whose rating cannot be fetched, is still
+	// returned, with a placeholder rating.
 	rating, err := c.ratingGateway.GetAggregatedRating(ctx, ratingmodel.MovieID(metadata.ID))
-	if err != nil && !errors.Is(err, gateway.ErrNotFound) {
-		placeholder := float64(0)
-		details.Rating = &placeholder
-	} else if err != nil {
+	details.Rating = &rating
+	if err != nil {
+		*details.Rating = 0
+		return details, errors.Is(err, gateway.ErrNotFound), nil
+	}
+
+	return details, true, nil
+}
+
+// PutRating records a rating for the given movie.
+func (c *Controller) PutRating(ctx context.Context, id int, rating int) error {
+	if err := c.ratingGateway.PutRating(ctx, ratingmodel.MovieID(id), ratingmodel.RatingValue(rating)); err != nil {
+		return err
+	}
+
+	// Fetches already underway do not put the previous rating back.
+	c.cache.delete(id)
+	return nil
+}
+
+// BatchGet returns one result per requested id, in request order. Ids without
+// metadata are reported as not found instead of failing the whole batch.
+func (c *Controller) BatchGet(ctx context.Context, ids []int) ([]model.MovieDetailsResult, error) {
+	metadata, err := c.metadataGateway.BatchGetMetadata(ctx, ids)
+	if err != nil {
 		return nil, err
-	} else {
-		details.Rating = &rating
 	}
 
-	return details, nil
+	details := c.withRatings(ctx, metadata)
+	byID := make(map[int]*model.MovieDetails, len(details))
+	for _, d := range details {
+		byID[d.Metadata.ID] = d
+	}
+
+	res := make([]model.MovieDetailsResult, len(ids))
+	for i, id := range ids {
+		res[i] = model.MovieDetailsResult{MovieID: id, Details: byID[id]}
+	}
+
+	return res, nil
+}
+
+// List returns the given page of movies and the number of the next page,
+// which is 0 when there are no more movies.
+func (c *Controller) List(ctx context.Context, page, pageSize int, sort model.Sort) ([]*model.MovieDetails, int, error) {
+	offset := (page - 1) * pageSize
+
+	var (
+		res     []*model.MovieDetails
+		hasMore bool
+	)
+	switch sort {
+	case model.SortRating:
+		ratings, err := c.ratingGateway.ListAggregatedRatings(ctx, pageSize+1, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(ratings) > pageSize {
+			ratings, hasMore = ratings[:pageSize], true
+		}
+		if len(ratings) == 0 {
+			break
+		}
+
+		ids := make([]int, len(ratings))
+		for i, r := range ratings {
+			ids[i] = int(r.MovieID)
+		}
+		metadata, err := c.metadataGateway.BatchGetMetadata(ctx, ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		byID := make(map[int]*metadatamodel.Metadata, len(metadata))
+		for _, m := range metadata {
+			byID[m.ID] = m
+		}
+
+		for _, r := range ratings {
+			m, ok := byID[int(r.MovieID)]
+			if !ok {
+				continue
+			}
+			rating := r.Rating
+			res = append(res, &model.MovieDetails{Metadata: *m, Rating: &rating})
+		}
+	default:
+		order := metadatamodel.OrderID
+		if sort == model.SortYear {
+			order = metadatamodel.OrderYearDesc
+		}
+		metadata, err := c.metadataGateway.ListMetadata(ctx, pageSize+1, offset, order, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(metadata) > pageSize {
+			metadata, hasMore = metadata[:pageSize], true
+		}
+		res = c.withRatings(ctx, metadata)
+	}
+
+	nextPage := 0
+	if hasMore {
+		nextPage = page + 1
+	}
+
+	return res, nextPage, nil
+}
+
+// withRatings merges metadata with aggregated ratings fetched in one batch.
+// Like Get, it falls back to a zero rating when ratings are unavailable.
+func (c *Controller) withRatings(ctx context.Context, metadata []*metadatamodel.Metadata) []*model.MovieDetails {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	ids := make([]ratingmodel.MovieID, len(metadata))
+	for i, m := range metadata {
+		ids[i] = ratingmodel.MovieID(m.ID)
+	}
+
+	ratings, err := c.ratingGateway.BatchGetAggregatedRatings(ctx, ids)
+	if err != nil {
+		ratings = nil
+	}
+
+	res := make([]*model.MovieDetails, len(metadata))
+	for i, m := range metadata {
+		rating := ratings[ratingmodel.MovieID(m.ID)]
+		res[i] = &model.MovieDetails{Metadata: *m, Rating: &rating}
+	}
+
+	return res
 }
@@ -0,0 +1,163 @@
+package movie
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metadatamodel "github.com/ochamekan/ms/metadataservice/pkg/model"
+	"github.com/ochamekan/ms/movieservice/internal/gateway"
+	"github.com/ochamekan/ms/pkg/metrics"
+	ratingmodel "github.com/ochamekan/ms/ratingservice/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubMetadataGateway serves metadata from a map, and lists total movies.
+// It records the deadline of the last GetMetadata call, and holds calls
+// until release is closed if it is set.
+type stubMetadataGateway struct {
+	metadata map[int]*metadatamodel.Metadata
+	total    int
+	release  chan struct{}
+
+	mu       sync.Mutex
+	deadline time.Time
+	calls    int
+	lists    int
+}
+
+func (g *stubMetadataGateway) GetMetadata(ctx context.Context, id int) (*metadatamodel.Metadata, bool, error) {
+	if g.release != nil {
+		<-g.release
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.deadline, _ = ctx.Deadline()
+	g.calls++
+	m, ok := g.metadata[id]
+	if !ok {
+		return nil, false, gateway.ErrNotFound
+	}
+	return m, false, nil
+}
+
+func (g *stubMetadataGateway) PutMetadata(context.Context, string, string, string, int) error {
+	return nil
+}
+
+func (g *stubMetadataGateway) BatchGetMetadata(context.Context, []int) ([]*metadatamodel.Metadata, error) {
+	return nil, nil
+}
+
+func (g *stubMetadataGateway) ListMetadata(_ context.Context, limit, offset int, _ metadatamodel.Order, _ []string) ([]*metadatamodel.Metadata, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.lists++
+	var res []*metadatamodel.Metadata
+	for id := offset + 1; id <= min(offset+limit, g.total); id++ {
+		res = append(res, &metadatamodel.Metadata{ID: id, Director: "Mann"})
+	}
+	return res, nil
+}
+
+// stubRatingGateway serves ratings from a map, or fails with err.
+type stubRatingGateway struct {
+	mu      sync.Mutex
+	ratings map[ratingmodel.MovieID]float64
+	err     error
+}
+
+func (g *stubRatingGateway) GetAggregatedRating(_ context.Context, movieID ratingmodel.MovieID) (float64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err != nil {
+		return 0, g.err
+	}
+	return g.ratings[movieID], nil
+}
+
+func (g *stubRatingGateway) PutRating(_ context.Context, movieID ratingmodel.MovieID, rating ratingmodel.RatingValue) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.ratings[movieID] = float64(rating)
+	return nil
+}
+
+func (g *stubRatingGateway) BatchGetAggregatedRatings(context.Context, []ratingmodel.MovieID) (map[ratingmodel.MovieID]float64, error) {
+	return nil, nil
+}
+
+func (g *stubRatingGateway) ListAggregatedRatings(context.Context, int, int) ([]ratingmodel.AggregatedRating, error) {
+	return nil, nil
+}
+
+func (g *stubRatingGateway) BatchGetRatingDistributions(context.Context, []ratingmodel.MovieID) (map[ratingmodel.MovieID]ratingmodel.Distribution, error) {
+	return nil, nil
+}
+
+func newController() (*Controller, *stubMetadataGateway, *stubRatingGateway) {
+	mg := &stubMetadataGateway{metadata: map[int]*metadatamodel.Metadata{1: {ID: 1, Title: "Heat", Director: "Mann"}}, total: 10}
+	rg := &stubRatingGateway{ratings: map[ratingmodel.MovieID]float64{1: 4}}
+	return New(rg, mg, time.Minute, metrics.New(prometheus.NewRegistry())), mg, rg
+}
+
+func TestGetKeepsCallerDeadline(t *testing.T) {
+	ctrl, mg, _ := newController()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if _, err := ctrl.Get(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := ctx.Deadline(); !mg.deadline.Equal(want) {
+		t.Errorf("got downstream deadline %v, want the caller's %v", mg.deadline, want)
+	}
+}
+
+func TestGetBoundsFetchWithoutCallerDeadline(t *testing.T) {
+	ctrl, mg, _ := newController()
+
+	start := time.Now()
+	if _, err := ctrl.Get(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if mg.deadline.Before(start.Add(sharedFetchTimeout)) || mg.deadline.After(time.Now().Add(sharedFetchTimeout)) {
+		t.Errorf("got downstream deadline in %v, want %v", mg.deadline.Sub(start), sharedFetchTimeout)
+	}
+}
+
+func TestGetCachesOnlyKnownRatings(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"rating", nil, 1},
+		{"no ratings", gateway.ErrNotFound, 1},
+		{"rating service down", status.Error(codes.Unavailable, "down"), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, mg, rg := newController()
+			rg.err = tt.err
+
+			for range 2 {
+				if _, err := ctrl.Get(context.Background(), 1); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if mg.calls != tt.wantCalls {
+				t.Errorf("got %d fetches, want %d", mg.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package movie
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	metadatamodel "github.com/ochamekan/ms/metadataservice/pkg/model"
+	"github.com/ochamekan/ms/movieservice/internal/gateway"
+	"github.com/ochamekan/ms/movieservice/pkg/model"
+	ratingmodel "github.com/ochamekan/ms/ratingservice/pkg/model"
+)
+
+const (
+	// similarTTL is how long a computed ranking is reused for a movie.
+	similarTTL = 10 * time.Minute
+	// maxSimilar is the length of the ranking kept per movie.
+	maxSimilar = 50
+
+	candidatePageSize = 100
+	maxCandidates     = 1000
+
+	// eraWindow is the difference in years past which release dates stop
+	// contributing to similarity.
+	eraWindow = 20
+
+	weightDirector = 0.35
+	weightTags     = 0.3
+	weightRatings  = 0.2
+	weightEra      = 0.15
+)
+
+type similarID struct {
+	id    int
+	score float64
+}
+
+// GetSimilar returns up to limit movies most similar to the given one, best
+// first. Rankings are cached per movie for similarTTL, and concurrent
+// requests for the same uncached movie share a single ranking.
+func (c *Controller) GetSimilar(ctx context.Context, id int, limit int) ([]model.SimilarMovie, error) {
+	ranking, err := c.similar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ranking = ranking[:min(limit, len(ranking))]
+	if len(ranking) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int, len(ranking))
+	for i, s := range ranking {
+		ids[i] = s.id
+	}
+
+	metadata, err := c.metadataGateway.BatchGetMetadata(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*model.MovieDetails, len(metadata))
+	for _, d := range c.withRatings(ctx, metadata) {
+		byID[d.Metadata.ID] = d
+	}
+
+	res := make([]model.SimilarMovie, 0, len(ranking))
+	for _, s := range ranking {
+		if d, ok := byID[s.id]; ok {
+			res = append(res, model.SimilarMovie{Details: *d, Score: s.score})
+		}
+	}
+
+	return res, nil
+}
+
+func (c *Controller) similar(ctx context.Context, id int) ([]similarID, error) {
+	if ranking, ok := c.similarCache.get(id); ok {
+		return ranking, nil
+	}
+
+	ch := c.group.DoChan("similar:"+strconv.Itoa(id), func() (any, error) {
+		fctx, cancel := sharedContext(ctx)
+		defer cancel()
+
+		gen := c.similarCache.generation()
+		ranking, err := c.rankSimilar(fctx, id)
+		if err == nil {
+			c.similarCache.put(id, ranking, gen)
+		}
+		return ranking, err
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// The ranking is shared with the other callers.
+		return slices.Clone(res.Val.([]similarID)), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// rankSimilar scores every known movie against the given one by shared
+// director, era proximity, tag overlap and rating patterns. Ratings are
+// anonymous, so co-rating is approximated by how alike the audience rated
+// both movies, i.e. the cosine similarity of their rating distributions.
+func (c *Controller) rankSimilar(ctx context.Context, id int) ([]similarID, error) {
+	target, _, err := c.metadataGateway.GetMetadata(ctx, id)
+	if err != nil && errors.Is(err, gateway.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var candidates []*metadatamodel.Metadata
+	for offset := 0; ; offset += candidatePageSize {
+		page, err := c.metadataGateway.ListMetadata(ctx, candidatePageSize, offset, metadatamodel.OrderID, nil)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, page...)
+		if len(page) < candidatePageSize {
+			break
+		}
+		// Movies past the cap may be left out of the ranking.
+		if len(candidates) >= maxCandidates {
+			c.metrics.IncSimilarTruncatedCount()
+			break
+		}
+	}
+
+	ids := make([]ratingmodel.MovieID, 0, len(candidates)+1)
+	ids = append(ids, ratingmodel.MovieID(id))
+	for _, m := range candidates {
+		if m.ID != id {
+			ids = append(ids, ratingmodel.MovieID(m.ID))
+		}
+	}
+
+	// Rating patterns are a soft signal, the ranking is still useful without them.
+	distributions := make(map[ratingmodel.MovieID]ratingmodel.Distribution, len(ids))
+	for chunk := range slices.Chunk(ids, candidatePageSize) {
+		d, err := c.ratingGateway.BatchGetRatingDistributions(ctx, chunk)
+		if err != nil {
+			break
+		}
+		for k, v := range d {
+			distributions[k] = v
+		}
+	}
+
+	targetDist := distributions[ratingmodel.MovieID(id)]
+	var res []similarID
+	for _, m := range candidates {
+		if m.ID == id {
+			continue
+		}
+
+		score := weightTags*jaccard(target.Tags, m.Tags) +
+			weightRatings*cosine(targetDist, distributions[ratingmodel.MovieID(m.ID)]) +
+			weightEra*math.Max(0, 1-math.Abs(float64(target.Year-m.Year))/eraWindow)
+		if target.Director == m.Director {
+			score += weightDirector
+		}
+
+		if score > 0 {
+			res = append(res, similarID{m.ID, score})
+		}
+	}
+
+	slices.SortFunc(res, func(a, b similarID) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+
+	return res[:min(maxSimilar, len(res))], nil
+}
+
+// jaccard returns the size of the intersection of a and b over the size of
+// their union.
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[t] = true
+	}
+
+	shared, union := 0, len(set)
+	for _, t := range b {
+		if set[t] {
+			shared++
+			set[t] = false
+		} else if _, seen := set[t]; !seen {
+			union++
+			set[t] = false
+		}
+	}
+
+	return float64(shared) / float64(union)
+}
+
+// cosine returns the cosine similarity of two rating distributions.
+func cosine(a, b ratingmodel.Distribution) float64 {
+	var dot, na, nb float64
+	for v, n := range a {
+		dot += float64(n) * float64(b[v])
+		na += float64(n) * float64(n)
+	}
+	for _, n := range b {
+		nb += float64(n) * float64(n)
+	}
+
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
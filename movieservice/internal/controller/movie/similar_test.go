@@ -0,0 +1,59 @@
+package movie
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGetSimilarSharesRanking(t *testing.T) {
+	ctrl, mg, _ := newController()
+	mg.release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			if _, err := ctrl.GetSimilar(context.Background(), 1, 3); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	// Lets the callers join the ranking started by the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(mg.release)
+	wg.Wait()
+
+	if mg.calls != 1 {
+		t.Errorf("got %d rankings, want 1", mg.calls)
+	}
+}
+
+func TestGetSimilarCountsTruncatedCandidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int
+		want  float64
+	}{
+		{"under the cap", maxCandidates - 1, 0},
+		{"over the cap", maxCandidates + 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, mg, _ := newController()
+			mg.total = tt.total
+
+			if _, err := ctrl.GetSimilar(context.Background(), 1, 3); err != nil {
+				t.Fatal(err)
+			}
+			if got := testutil.ToFloat64(ctrl.metrics.SimilarTruncated); got != tt.want {
+				t.Errorf("got %v truncated rankings, want %v", got, tt.want)
+			}
+			if mg.lists > maxCandidates/candidatePageSize {
+				t.Errorf("got %d pages of candidates, want at most %d", mg.lists, maxCandidates/candidatePageSize)
+			}
+		})
+	}
+}
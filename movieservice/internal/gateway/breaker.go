@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ochamekan/ms/internal/loadshed"
+	"github.com/ochamekan/ms/pkg/logging"
+	"github.com/ochamekan/ms/pkg/metrics"
+	"github.com/sony/gobreaker"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned without calling the service while its circuit
+// breaker is open.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "circuit breaker is open")
+
+// BreakerConfig holds the thresholds of a circuit breaker.
+type BreakerConfig struct {
+	// MinRequests is the number of calls within Interval needed before the
+	// breaker can trip.
+	MinRequests uint32
+	// FailureRatio is the share of failed calls within Interval that trips
+	// the breaker.
+	FailureRatio float64
+	// Interval is how often the counts are cleared while closed.
+	Interval time.Duration
+	// OpenTimeout is how long the breaker fails fast before letting trial
+	// calls through.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of trial calls allowed while half-open.
+	// The breaker closes once they all succeed.
+	HalfOpenRequests uint32
+}
+
+var DefaultBreakerConfig = BreakerConfig{
+	MinRequests:      20,
+	FailureRatio:     0.5,
+	Interval:         10 * time.Second,
+	OpenTimeout:      5 * time.Second,
+	HalfOpenRequests: 5,
+}
+
+// BreakerConfigFromEnv returns DefaultBreakerConfig with the thresholds set
+// in <prefix>_BREAKER_MIN_REQUESTS, <prefix>_BREAKER_FAILURE_RATIO,
+// <prefix>_BREAKER_INTERVAL, <prefix>_BREAKER_OPEN_TIMEOUT and
+// <prefix>_BREAKER_HALF_OPEN_REQUESTS.
+func BreakerConfigFromEnv(prefix string) (BreakerConfig, error) {
+	cfg := DefaultBreakerConfig
+	prefix += "_BREAKER_"
+
+	if v := os.Getenv(prefix + "MIN_REQUESTS"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return BreakerConfig{}, fmt.Errorf("%sMIN_REQUESTS: %w", prefix, err)
+		}
+		cfg.MinRequests = uint32(n)
+	}
+	if v := os.Getenv(prefix + "FAILURE_RATIO"); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r <= 0 || r > 1 {
+			return BreakerConfig{}, fmt.Errorf("%sFAILURE_RATIO must be in (0, 1], got %q", prefix, v)
+		}
+		cfg.FailureRatio = r
+	}
+	if v := os.Getenv(prefix + "INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return BreakerConfig{}, fmt.Errorf("%sINTERVAL: %w", prefix, err)
+		}
+		cfg.Interval = d
+	}
+	if v := os.Getenv(prefix + "OPEN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return BreakerConfig{}, fmt.Errorf("%sOPEN_TIMEOUT: %w", prefix, err)
+		}
+		cfg.OpenTimeout = d
+	}
+	if v := os.Getenv(prefix + "HALF_OPEN_REQUESTS"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return BreakerConfig{}, fmt.Errorf("%sHALF_OPEN_REQUESTS: %w", prefix, err)
+		}
+		cfg.HalfOpenRequests = uint32(n)
+	}
+
+	return cfg, nil
+}
+
+// Breaker is a circuit breaker for the calls to one service.
+type Breaker struct {
+	cb *gobreaker.CircuitBreaker
+}
+
+// NewBreaker creates a closed circuit breaker for the given service. State
+// changes are logged and exported to metrics.
+func NewBreaker(service string, cfg BreakerConfig, logger *zap.Logger, metrics *metrics.Metrics) *Breaker {
+	logger = logger.With(zap.String(logging.FieldComponent, "circuit breaker"), zap.String("downstream", service))
+	metrics.SetCircuitBreakerState(service, int(gobreaker.StateClosed))
+
+	return &Breaker{gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        service,
+		MaxRequests: cfg.HalfOpenRequests,
+		Interval:    cfg.Interval,
+		Timeout:     cfg.OpenTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.Requests >= cfg.MinRequests && float64(counts.TotalFailures) >= cfg.FailureRatio*float64(counts.Requests)
+		},
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			logger.Warn("Circuit breaker state changed", zap.Stringer("from", from), zap.Stringer("to", to))
+			metrics.SetCircuitBreakerState(name, int(to))
+		},
+		IsSuccessful: isSuccessful,
+	})}
+}
+
+// Wrap returns a connection whose calls go through the breaker.
+func (b *Breaker) Wrap(conn grpc.ClientConnInterface) grpc.ClientConnInterface {
+	return &breakerConn{conn, b.cb}
+}
+
+// isSuccessful reports whether a call shows the service is working. Errors
+// caused by the request itself, such as NotFound, do not count as failures.
+// Neither do calls shed by an overloaded instance, which is up and only
+// needs less traffic, not none.
+func isSuccessful(err error) bool {
+	if loadshed.IsOverloaded(err) {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return false
+	}
+	return true
+}
+
+type breakerConn struct {
+	grpc.ClientConnInterface
+	cb *gobreaker.CircuitBreaker
+}
+
+func (c *breakerConn) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	_, err := c.cb.Execute(func() (any, error) {
+		return nil, c.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return ErrCircuitOpen
+	}
+	return err
+}
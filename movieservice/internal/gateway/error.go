@@ -1,5 +1,19 @@
 package gateway
 
-import "errors"
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
 
 var ErrNotFound = errors.New("not found")
+
+// FromStatus converts the status errors of a service that callers check for
+// into the errors of this package.
+func FromStatus(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	return err
+}
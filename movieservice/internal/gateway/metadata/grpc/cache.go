@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/ochamekan/ms/metadataservice/pkg/model"
+)
+
+// CacheConfig bounds the metadata kept by the gateway.
+type CacheConfig struct {
+	// SoftTTL is how long metadata is served without asking the metadata
+	// service. Older metadata is still served, and refreshed in the
+	// background.
+	SoftTTL time.Duration
+	// MaxStale is how long metadata is kept, and served while the metadata
+	// service cannot be reached to refresh it.
+	MaxStale time.Duration
+	// MaxEntries bounds the number of movies kept.
+	MaxEntries int
+}
+
+var DefaultCacheConfig = CacheConfig{
+	SoftTTL:    time.Minute,
+	MaxStale:   24 * time.Hour,
+	MaxEntries: 10000,
+}
+
+type cacheEntry struct {
+	metadata  model.Metadata
+	fetchedAt time.Time
+	// stale is set when the last refresh failed.
+	stale bool
+}
+
+// metadataCache keeps the last metadata fetched for each movie. When full,
+// the least recently used movie is evicted. Entries older than MaxStale are
+// dropped by the LRU, and never served in case they outlive it after being
+// marked stale.
+type metadataCache struct {
+	config CacheConfig
+
+	// mu orders markStale with put, so that a failed refresh does not
+	// overwrite fresher metadata.
+	mu      sync.Mutex
+	entries *expirable.LRU[int, cacheEntry]
+}
+
+func newMetadataCache(config CacheConfig) *metadataCache {
+	return &metadataCache{
+		config:  config,
+		entries: expirable.NewLRU[int, cacheEntry](config.MaxEntries, nil, config.MaxStale),
+	}
+}
+
+// get returns the cached metadata of a movie, whether it is due for a
+// refresh and whether the last refresh failed.
+func (c *metadataCache) get(id int) (m *model.Metadata, expired bool, stale bool, ok bool) {
+	e, ok := c.entries.Get(id)
+	if !ok {
+		return nil, false, false, false
+	}
+	age := time.Since(e.fetchedAt)
+	if age > c.config.MaxStale {
+		return nil, false, false, false
+	}
+
+	md := e.metadata
+	md.Tags = slices.Clone(md.Tags)
+	return &md, age > c.config.SoftTTL, e.stale, true
+}
+
+func (c *metadataCache) put(m *model.Metadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := cacheEntry{metadata: *m, fetchedAt: time.Now()}
+	e.metadata.Tags = slices.Clone(m.Tags)
+	c.entries.Add(m.ID, e)
+}
+
+func (c *metadataCache) markStale(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries.Peek(id); ok {
+		e.stale = true
+		c.entries.Add(id, e)
+	}
+}
+
+func (c *metadataCache) delete(id int) {
+	c.entries.Remove(id)
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ochamekan/ms/metadataservice/pkg/model"
+)
+
+func TestMetadataCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newMetadataCache(CacheConfig{SoftTTL: time.Minute, MaxStale: time.Hour, MaxEntries: 2})
+
+	c.put(&model.Metadata{ID: 1})
+	c.put(&model.Metadata{ID: 2})
+	if _, _, _, ok := c.get(1); !ok {
+		t.Fatal("movie 1 not cached")
+	}
+	c.put(&model.Metadata{ID: 3})
+
+	for id, want := range map[int]bool{1: true, 2: false, 3: true} {
+		if _, _, _, ok := c.get(id); ok != want {
+			t.Errorf("movie %d cached: got %v, want %v", id, ok, want)
+		}
+	}
+}
+
+func TestMetadataCacheClonesTags(t *testing.T) {
+	c := newMetadataCache(DefaultCacheConfig)
+
+	m := &model.Metadata{ID: 1, Tags: []string{"drama"}}
+	c.put(m)
+	m.Tags[0] = "changed"
+
+	got, _, _, _ := c.get(1)
+	got.Tags[0] = "changed too"
+
+	if got, _, _, _ := c.get(1); got.Tags[0] != "drama" {
+		t.Errorf("got tag %q, want %q", got.Tags[0], "drama")
+	}
+}
+
+func TestMetadataCacheMarkStale(t *testing.T) {
+	c := newMetadataCache(DefaultCacheConfig)
+
+	c.put(&model.Metadata{ID: 1})
+	c.markStale(1)
+	if _, _, stale, ok := c.get(1); !ok || !stale {
+		t.Errorf("got stale %v, ok %v, want both true", stale, ok)
+	}
+
+	c.put(&model.Metadata{ID: 1})
+	if _, _, stale, _ := c.get(1); stale {
+		t.Error("refreshed metadata still stale")
+	}
+
+	c.markStale(2)
+	if _, _, _, ok := c.get(2); ok {
+		t.Error("marking an uncached movie stale cached it")
+	}
+}
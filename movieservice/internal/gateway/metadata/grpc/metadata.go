@@ -2,75 +2,122 @@ package grpc
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/ochamekan/ms/gen"
-	"github.com/ochamekan/ms/internal/grpcutil"
 	"github.com/ochamekan/ms/metadataservice/pkg/model"
-	"github.com/ochamekan/ms/pkg/discovery"
-	"github.com/ochamekan/ms/pkg/logging"
-	"go.uber.org/zap"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/ochamekan/ms/movieservice/internal/gateway"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
 )
 
+// refreshTimeout bounds a background refresh of cached metadata.
+const refreshTimeout = 5 * time.Second
+
 type Gateway struct {
-	registry discovery.Registry
-	logger   *zap.Logger
+	client    gen.MetadataServiceClient
+	cache     *metadataCache
+	refreshes singleflight.Group
 }
 
-func New(registry discovery.Registry, logger *zap.Logger) *Gateway {
-	return &Gateway{registry, logger.With(zap.String(logging.FieldComponent, "movie service metadata gateway"))}
+// New creates a gateway over a shared connection to the metadata service,
+// caching the metadata of single movies as set in cacheConfig.
+func New(conn grpc.ClientConnInterface, cacheConfig CacheConfig) *Gateway {
+	return &Gateway{client: gen.NewMetadataServiceClient(conn), cache: newMetadataCache(cacheConfig)}
 }
 
-func (g *Gateway) GetMetadata(ctx context.Context, id int) (*model.Metadata, error) {
-	logger := g.logger.With(zap.String(logging.FieldEndpoint, "GetMetadata"))
-	conn, err := grpcutil.ServiceConnection(ctx, "metadata", g.registry)
+// GetMetadata returns the metadata of a movie. Cached metadata is returned
+// without calling the metadata service, and refreshed in the background
+// once older than SoftTTL. While refreshing fails, e.g. because the service
+// is down or its circuit breaker is open, the cached metadata is still
+// returned, up to MaxStale old, with stale set.
+func (g *Gateway) GetMetadata(ctx context.Context, id int) (m *model.Metadata, stale bool, err error) {
+	if m, expired, stale, ok := g.cache.get(id); ok {
+		if expired {
+			g.refresh(ctx, id)
+		}
+		return m, stale, nil
+	}
+
+	m, err = g.fetch(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	defer conn.Close()
-
-	client := gen.NewMetadataServiceClient(conn)
-	var resp *gen.GetMetadataResponse
-
-	// If error is retriable, try 5 times and if no success return err
-	const maxRetries = 5
-	for i := range 5 {
-		resp, err = client.GetMetadata(ctx, &gen.GetMetadataRequest{Id: int32(id)})
-		if err != nil {
-			if shouldRetry(err) {
-				logger.Warn("Failed to get metadata", zap.Int("attempt number", i+1), zap.Error(err))
-				continue
-			}
-			return nil, err
+	return m, false, nil
+}
+
+// refresh fetches the metadata of a movie in the background, unless that
+// is already underway.
+func (g *Gateway) refresh(ctx context.Context, id int) {
+	// The caller is served the cached metadata without waiting, and may be
+	// gone before the fetch completes.
+	ctx = context.WithoutCancel(ctx)
+
+	g.refreshes.DoChan(strconv.Itoa(id), func() (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+		defer cancel()
+
+		_, err := g.fetch(ctx, id)
+		if errors.Is(err, gateway.ErrNotFound) {
+			g.cache.delete(id)
+		} else if err != nil {
+			g.cache.markStale(id)
 		}
-		return model.MetadataFromProto(resp.Metadata), nil
+		return nil, err
+	})
+}
+
+func (g *Gateway) fetch(ctx context.Context, id int) (*model.Metadata, error) {
+	resp, err := g.client.GetMetadata(ctx, &gen.GetMetadataRequest{Id: int32(id)})
+	if err != nil {
+		return nil, gateway.FromStatus(err)
 	}
 
-	return nil, fmt.Errorf("GetMetadata failed after %d retries: %s", maxRetries, err.Error())
+	m := model.MetadataFromProto(resp.Metadata)
+	g.cache.put(m)
+	return m, nil
 }
 
 func (g *Gateway) PutMetadata(ctx context.Context, title, description, director string, year int) error {
-	conn, err := grpcutil.ServiceConnection(ctx, "metadata", g.registry)
+	_, err := g.client.PutMetadata(ctx, &gen.PutMetadataRequest{Title: title, Description: description, Year: int32(year), Director: director})
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
 
-	client := gen.NewMetadataServiceClient(conn)
-	_, err = client.PutMetadata(ctx, &gen.PutMetadataRequest{Title: title, Description: description, Year: int32(year), Director: director})
+	return nil
+}
+
+func (g *Gateway) BatchGetMetadata(ctx context.Context, ids []int) ([]*model.Metadata, error) {
+	req := &gen.BatchGetMetadataRequest{Ids: make([]int32, len(ids))}
+	for i, id := range ids {
+		req.Ids[i] = int32(id)
+	}
+
+	resp, err := g.client.BatchGetMetadata(ctx, req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	res := make([]*model.Metadata, len(resp.Metadata))
+	for i, m := range resp.Metadata {
+		res[i] = model.MetadataFromProto(m)
+	}
+
+	return res, nil
 }
 
-func shouldRetry(err error) bool {
-	e, ok := status.FromError(err)
-	if !ok {
-		return false
+func (g *Gateway) ListMetadata(ctx context.Context, limit, offset int, order model.Order, directors []string) ([]*model.Metadata, error) {
+	resp, err := g.client.ListMetadata(ctx, &gen.ListMetadataRequest{Limit: int32(limit), Offset: int32(offset), Order: model.OrderToProto(order), Directors: directors})
+	if err != nil {
+		return nil, err
 	}
-	return e.Code() == codes.DeadlineExceeded || e.Code() == codes.ResourceExhausted || e.Code() == codes.Unavailable
+
+	res := make([]*model.Metadata, len(resp.Metadata))
+	for i, m := range resp.Metadata {
+		res[i] = model.MetadataFromProto(m)
+	}
+
+	return res, nil
 }
@@ -2,48 +2,93 @@ package grpc
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/ochamekan/ms/gen"
 	"github.com/ochamekan/ms/internal/grpcutil"
-	"github.com/ochamekan/ms/pkg/discovery"
+	"github.com/ochamekan/ms/movieservice/internal/gateway"
 	"github.com/ochamekan/ms/ratingservice/pkg/model"
+	"google.golang.org/grpc"
 )
 
 type Gateway struct {
-	registry discovery.Registry
+	client gen.RatingServiceClient
 }
 
-func New(registry discovery.Registry) *Gateway {
-	return &Gateway{registry}
+// New creates a gateway over a shared connection to the rating service.
+func New(conn grpc.ClientConnInterface) *Gateway {
+	return &Gateway{gen.NewRatingServiceClient(conn)}
 }
 
 func (g *Gateway) GetAggregatedRating(ctx context.Context, movieID model.MovieID) (float64, error) {
-	conn, err := grpcutil.ServiceConnection(ctx, "rating", g.registry)
+	ctx = grpcutil.WithHashKey(ctx, strconv.Itoa(int(movieID)))
+	resp, err := g.client.GetAggregatedRating(ctx, &gen.GetAggregatedRatingRequest{MovieId: int32(movieID)})
 	if err != nil {
-		return 0, err
+		return 0, gateway.FromStatus(err)
 	}
-	defer conn.Close()
 
-	client := gen.NewRatingServiceClient(conn)
+	return resp.Rating, nil
+}
+
+func (g *Gateway) PutRating(ctx context.Context, movieID model.MovieID, rating model.RatingValue) error {
+	ctx = grpcutil.WithHashKey(ctx, strconv.Itoa(int(movieID)))
+	_, err := g.client.PutRating(ctx, &gen.PutRatingRequest{MovieId: int32(movieID), Rating: int32(rating)})
+
+	return err
+}
+
+func (g *Gateway) BatchGetAggregatedRatings(ctx context.Context, movieIDs []model.MovieID) (map[model.MovieID]float64, error) {
+	req := &gen.BatchGetAggregatedRatingsRequest{MovieIds: make([]int32, len(movieIDs))}
+	for i, id := range movieIDs {
+		req.MovieIds[i] = int32(id)
+	}
 
-	resp, err := client.GetAggregatedRating(ctx, &gen.GetAggregatedRatingRequest{MovieId: int32(movieID)})
+	resp, err := g.client.BatchGetAggregatedRatings(ctx, req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return resp.Rating, nil
+	res := make(map[model.MovieID]float64, len(resp.Ratings))
+	for _, r := range resp.Ratings {
+		res[model.MovieID(r.MovieId)] = r.Rating
+	}
+
+	return res, nil
 }
 
-func (g *Gateway) PutRating(ctx context.Context, movieID model.MovieID, rating model.RatingValue) error {
-	conn, err := grpcutil.ServiceConnection(ctx, "rating", g.registry)
+func (g *Gateway) ListAggregatedRatings(ctx context.Context, limit, offset int) ([]model.AggregatedRating, error) {
+	resp, err := g.client.ListAggregatedRatings(ctx, &gen.ListAggregatedRatingsRequest{Limit: int32(limit), Offset: int32(offset)})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	res := make([]model.AggregatedRating, len(resp.Ratings))
+	for i, r := range resp.Ratings {
+		res[i] = model.AggregatedRating{MovieID: model.MovieID(r.MovieId), Rating: r.Rating}
 	}
-	defer conn.Close()
 
-	client := gen.NewRatingServiceClient(conn)
+	return res, nil
+}
 
-	_, err = client.PutRating(ctx, &gen.PutRatingRequest{MovieId: int32(movieID), Rating: int32(rating)})
+func (g *Gateway) BatchGetRatingDistributions(ctx context.Context, movieIDs []model.MovieID) (map[model.MovieID]model.Distribution, error) {
+	req := &gen.BatchGetRatingDistributionsRequest{MovieIds: make([]int32, len(movieIDs))}
+	for i, id := range movieIDs {
+		req.MovieIds[i] = int32(id)
+	}
 
-	return err
+	resp, err := g.client.BatchGetRatingDistributions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[model.MovieID]model.Distribution, len(resp.Distributions))
+	for _, d := range resp.Distributions {
+		dist := make(model.Distribution, len(d.Counts))
+		for v, n := range d.Counts {
+			dist[model.RatingValue(v)] = n
+		}
+		res[model.MovieID(d.MovieId)] = dist
+	}
+
+	return res, nil
 }
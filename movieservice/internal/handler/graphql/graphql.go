@@ -0,0 +1,283 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	metadatamodel "github.com/ochamekan/ms/metadataservice/pkg/model"
+	"github.com/ochamekan/ms/pkg/logging"
+	ratingmodel "github.com/ochamekan/ms/ratingservice/pkg/model"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+//go:embed schema.graphql
+var schema string
+
+const (
+	// maxBatchSize matches the batch limit of the downstream services.
+	maxBatchSize = 100
+	maxDepth     = 8
+	// maxDirectorQueries bounds the movies by director listed at once.
+	maxDirectorQueries = 10
+)
+
+var errIncorrectID = errors.New("movie ids must be positive")
+
+type metadataGateway interface {
+	BatchGetMetadata(ctx context.Context, ids []int) ([]*metadatamodel.Metadata, error)
+	ListMetadata(ctx context.Context, limit, offset int, order metadatamodel.Order, directors []string) ([]*metadatamodel.Metadata, error)
+}
+
+type ratingGateway interface {
+	BatchGetAggregatedRatings(ctx context.Context, movieIDs []ratingmodel.MovieID) (map[ratingmodel.MovieID]float64, error)
+	BatchGetRatingDistributions(ctx context.Context, movieIDs []ratingmodel.MovieID) (map[ratingmodel.MovieID]ratingmodel.Distribution, error)
+}
+
+// Handler serves GraphQL queries over the movie domain. Every request gets
+// its own set of loaders, so lookups are batched and memoized per request.
+type Handler struct {
+	relay           *relay.Handler
+	metadataGateway metadataGateway
+	ratingGateway   ratingGateway
+	logger          *zap.Logger
+}
+
+func New(mg metadataGateway, rg ratingGateway, logger *zap.Logger) (*Handler, error) {
+	s, err := graphql.ParseSchema(schema, &resolver{}, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		relay:           &relay.Handler{Schema: s},
+		metadataGateway: mg,
+		ratingGateway:   rg,
+		logger:          logger.With(zap.String(logging.FieldComponent, "movie graphql handler")),
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), loadersKey{}, h.newLoaders(r.Context()))
+	h.relay.ServeHTTP(w, r.WithContext(ctx))
+}
+
+type loadersKey struct{}
+
+type loaders struct {
+	metadata      *loader[int, *metadatamodel.Metadata]
+	ratings       *loader[ratingmodel.MovieID, float64]
+	distributions *loader[ratingmodel.MovieID, ratingmodel.Distribution]
+	directors     *loader[string, []*metadatamodel.Metadata]
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (h *Handler) newLoaders(ctx context.Context) *loaders {
+	l := &loaders{}
+
+	l.metadata = newLoader(ctx, maxBatchSize, func(ctx context.Context, ids []int) (map[int]*metadatamodel.Metadata, error) {
+		ms, err := h.metadataGateway.BatchGetMetadata(ctx, ids)
+		if err != nil {
+			h.logger.Error("Failed to load metadata", zap.Error(err))
+			return nil, err
+		}
+
+		res := make(map[int]*metadatamodel.Metadata, len(ms))
+		for _, m := range ms {
+			res[m.ID] = m
+		}
+		return res, nil
+	})
+
+	l.ratings = newLoader(ctx, maxBatchSize, func(ctx context.Context, ids []ratingmodel.MovieID) (map[ratingmodel.MovieID]float64, error) {
+		res, err := h.ratingGateway.BatchGetAggregatedRatings(ctx, ids)
+		if err != nil {
+			h.logger.Error("Failed to load ratings", zap.Error(err))
+		}
+		return res, err
+	})
+
+	l.distributions = newLoader(ctx, maxBatchSize, func(ctx context.Context, ids []ratingmodel.MovieID) (map[ratingmodel.MovieID]ratingmodel.Distribution, error) {
+		res, err := h.ratingGateway.BatchGetRatingDistributions(ctx, ids)
+		if err != nil {
+			h.logger.Error("Failed to load rating distributions", zap.Error(err))
+		}
+		return res, err
+	})
+
+	// Movies are listed per director, so that the limit applies to each of
+	// them rather than letting a prolific one crowd out the rest.
+	l.directors = newLoader(ctx, maxBatchSize, func(ctx context.Context, directors []string) (map[string][]*metadatamodel.Metadata, error) {
+		var mu sync.Mutex
+		res := make(map[string][]*metadatamodel.Metadata, len(directors))
+
+		g, ctx := errgroup.WithContext(ctx)
+		g.SetLimit(maxDirectorQueries)
+		for _, d := range directors {
+			g.Go(func() error {
+				ms, err := h.metadataGateway.ListMetadata(ctx, maxBatchSize, 0, metadatamodel.OrderYearDesc, []string{d})
+				if err != nil {
+					return err
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				res[d] = ms
+				for _, m := range ms {
+					l.metadata.Prime(m.ID, m)
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			h.logger.Error("Failed to load movies by director", zap.Error(err))
+			return nil, err
+		}
+		return res, nil
+	})
+
+	return l
+}
+
+type resolver struct{}
+
+// Invalid ids are rejected before they are loaded, since the downstream
+// services fail the whole batch they are in.
+func (r *resolver) Movie(ctx context.Context, args struct{ ID int32 }) (*movieResolver, error) {
+	if args.ID <= 0 {
+		return nil, errIncorrectID
+	}
+
+	m, ok, err := loadersFrom(ctx).metadata.Load(ctx, int(args.ID))
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return &movieResolver{m}, nil
+}
+
+func (r *resolver) Movies(ctx context.Context, args struct{ IDs []int32 }) ([]*movieResolver, error) {
+	if len(args.IDs) > maxBatchSize {
+		return nil, fmt.Errorf("at most %d ids can be requested at once", maxBatchSize)
+	}
+	ids := make([]int, len(args.IDs))
+	for i, id := range args.IDs {
+		if id <= 0 {
+			return nil, errIncorrectID
+		}
+		ids[i] = int(id)
+	}
+
+	ms, found, err := loadersFrom(ctx).metadata.LoadMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*movieResolver, len(ids))
+	for i, m := range ms {
+		if found[i] {
+			res[i] = &movieResolver{m}
+		}
+	}
+	return res, nil
+}
+
+type movieResolver struct {
+	m *metadatamodel.Metadata
+}
+
+func (r *movieResolver) ID() int32 {
+	return int32(r.m.ID)
+}
+
+func (r *movieResolver) Metadata() *metadataResolver {
+	return &metadataResolver{r.m}
+}
+
+func (r *movieResolver) Rating() *ratingResolver {
+	return &ratingResolver{ratingmodel.MovieID(r.m.ID)}
+}
+
+func (r *movieResolver) Related(ctx context.Context, args struct{ Limit int32 }) ([]*movieResolver, error) {
+	ms, _, err := loadersFrom(ctx).directors.Load(ctx, r.m.Director)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*movieResolver{}
+	for _, m := range ms {
+		if len(res) >= int(args.Limit) {
+			break
+		}
+		if m.ID != r.m.ID {
+			res = append(res, &movieResolver{m})
+		}
+	}
+
+	return res, nil
+}
+
+type metadataResolver struct {
+	m *metadatamodel.Metadata
+}
+
+func (r *metadataResolver) Title() string       { return r.m.Title }
+func (r *metadataResolver) Description() string { return r.m.Description }
+func (r *metadataResolver) Year() int32         { return int32(r.m.Year) }
+func (r *metadataResolver) Director() string    { return r.m.Director }
+
+type ratingResolver struct {
+	movieID ratingmodel.MovieID
+}
+
+// Average is 0 for movies without ratings, like GetMovieDetails.
+func (r *ratingResolver) Average(ctx context.Context) (float64, error) {
+	v, _, err := loadersFrom(ctx).ratings.Load(ctx, r.movieID)
+	return v, err
+}
+
+func (r *ratingResolver) Count(ctx context.Context) (int32, error) {
+	d, _, err := loadersFrom(ctx).distributions.Load(ctx, r.movieID)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	for _, c := range d {
+		n += c
+	}
+	return int32(n), nil
+}
+
+func (r *ratingResolver) Distribution(ctx context.Context) ([]*ratingBucketResolver, error) {
+	d, _, err := loadersFrom(ctx).distributions.Load(ctx, r.movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*ratingBucketResolver, 0, len(d))
+	for v, n := range d {
+		res = append(res, &ratingBucketResolver{v, n})
+	}
+	slices.SortFunc(res, func(a, b *ratingBucketResolver) int { return int(a.value - b.value) })
+
+	return res, nil
+}
+
+type ratingBucketResolver struct {
+	value ratingmodel.RatingValue
+	count int64
+}
+
+func (r *ratingBucketResolver) Value() int32 { return int32(r.value) }
+func (r *ratingBucketResolver) Count() int32 { return int32(r.count) }
@@ -0,0 +1,134 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	metadatamodel "github.com/ochamekan/ms/metadataservice/pkg/model"
+	ratingmodel "github.com/ochamekan/ms/ratingservice/pkg/model"
+	"go.uber.org/zap"
+)
+
+// stubMetadataGateway serves the movies with ids up to n and records the
+// batches requested.
+type stubMetadataGateway struct {
+	n int
+
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (g *stubMetadataGateway) BatchGetMetadata(_ context.Context, ids []int) ([]*metadatamodel.Metadata, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.batches = append(g.batches, ids)
+	var res []*metadatamodel.Metadata
+	for _, id := range ids {
+		if id <= g.n {
+			res = append(res, &metadatamodel.Metadata{ID: id})
+		}
+	}
+	return res, nil
+}
+
+func (g *stubMetadataGateway) ListMetadata(context.Context, int, int, metadatamodel.Order, []string) ([]*metadatamodel.Metadata, error) {
+	return nil, nil
+}
+
+type stubRatingGateway struct{}
+
+func (stubRatingGateway) BatchGetAggregatedRatings(context.Context, []ratingmodel.MovieID) (map[ratingmodel.MovieID]float64, error) {
+	return nil, nil
+}
+
+func (stubRatingGateway) BatchGetRatingDistributions(context.Context, []ratingmodel.MovieID) (map[ratingmodel.MovieID]ratingmodel.Distribution, error) {
+	return nil, nil
+}
+
+type response struct {
+	Data struct {
+		Movies []*struct{ ID int }
+	}
+	Errors []struct{ Message string }
+}
+
+func query(t *testing.T, h http.Handler, ids []int) response {
+	t.Helper()
+
+	q := fmt.Sprintf(`{"query": "{ movies(ids: %s) { id } }"}`, strings.Join(strings.Fields(fmt.Sprint(ids)), ","))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(q)))
+
+	var res response
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestMoviesLoadsOneBatch(t *testing.T) {
+	mg := &stubMetadataGateway{n: 2}
+	h, err := New(mg, stubRatingGateway{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := query(t, h, []int{2, 3, 1})
+	if len(res.Errors) > 0 {
+		t.Fatalf("got errors %v", res.Errors)
+	}
+	if len(mg.batches) != 1 {
+		t.Errorf("got %d batches, want 1", len(mg.batches))
+	}
+
+	var got []int
+	for _, m := range res.Data.Movies {
+		id := 0
+		if m != nil {
+			id = m.ID
+		}
+		got = append(got, id)
+	}
+	if fmt.Sprint(got) != "[2 0 1]" {
+		t.Errorf("got movies %v, want [2 0 1] with 0 for null", got)
+	}
+}
+
+func TestMoviesRejectsInvalidIDs(t *testing.T) {
+	tooMany := make([]int, maxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+
+	tests := []struct {
+		name string
+		ids  []int
+	}{
+		{"zero id", []int{1, 0}},
+		{"negative id", []int{-1, 1}},
+		{"too many ids", tooMany},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mg := &stubMetadataGateway{n: maxBatchSize}
+			h, err := New(mg, stubRatingGateway{}, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res := query(t, h, tt.ids); len(res.Errors) == 0 {
+				t.Error("got no errors")
+			}
+			if len(mg.batches) != 0 {
+				t.Errorf("got %d downstream batches, want none", len(mg.batches))
+			}
+		})
+	}
+}
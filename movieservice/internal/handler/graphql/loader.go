@@ -0,0 +1,134 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// loader batches individual key lookups issued within a short window into a
+// single fetch, so resolving a list of movies does not fan out into one
+// downstream call per movie. Results are memoized for the loader's lifetime,
+// which is a single GraphQL request.
+type loader[K comparable, V any] struct {
+	ctx      context.Context
+	fetch    func(ctx context.Context, keys []K) (map[K]V, error)
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	batches map[K]*batch[K, V]
+	pending *batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys []K
+	once sync.Once
+	done chan struct{}
+	res  map[K]V
+	err  error
+}
+
+func newLoader[K comparable, V any](ctx context.Context, maxBatch int, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		wait:     2 * time.Millisecond,
+		maxBatch: maxBatch,
+		batches:  make(map[K]*batch[K, V]),
+	}
+}
+
+// Load returns the value for key and whether it was found.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, bool, error) {
+	l.mu.Lock()
+	b := l.enqueue(key)
+	l.mu.Unlock()
+
+	return b.wait(ctx, key)
+}
+
+// LoadMany returns the values for keys, in order, and whether each was
+// found. The keys are queued at once, so that they fill as few batches as
+// possible.
+func (l *loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, []bool, error) {
+	l.mu.Lock()
+	batches := make([]*batch[K, V], len(keys))
+	for i, key := range keys {
+		batches[i] = l.enqueue(key)
+	}
+	l.mu.Unlock()
+
+	vals := make([]V, len(keys))
+	found := make([]bool, len(keys))
+	for i, b := range batches {
+		var err error
+		if vals[i], found[i], err = b.wait(ctx, keys[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return vals, found, nil
+}
+
+// enqueue returns the batch that fetches key, adding key to the pending
+// batch if no batch has it yet. l.mu must be held.
+func (l *loader[K, V]) enqueue(key K) *batch[K, V] {
+	if b, ok := l.batches[key]; ok {
+		return b
+	}
+
+	b := l.pending
+	if b == nil {
+		b = &batch[K, V]{done: make(chan struct{})}
+		l.pending = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+	b.keys = append(b.keys, key)
+	l.batches[key] = b
+	if len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.dispatch(b)
+	}
+	return b
+}
+
+func (b *batch[K, V]) wait(ctx context.Context, key K) (V, bool, error) {
+	var zero V
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return zero, false, ctx.Err()
+	}
+
+	if b.err != nil {
+		return zero, false, b.err
+	}
+	v, ok := b.res[key]
+	return v, ok, nil
+}
+
+// Prime stores an already known value so that later loads skip the fetch.
+func (l *loader[K, V]) Prime(key K, v V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.batches[key]; ok {
+		return
+	}
+
+	b := &batch[K, V]{keys: []K{key}, done: make(chan struct{}), res: map[K]V{key: v}}
+	b.once.Do(func() { close(b.done) })
+	l.batches[key] = b
+}
+
+func (l *loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending == b {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+
+	b.once.Do(func() {
+		b.res, b.err = l.fetch(l.ctx, b.keys)
+		close(b.done)
+	})
+}
@@ -3,11 +3,12 @@ package grpc
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
 	"github.com/ochamekan/ms/gen"
-	"github.com/ochamekan/ms/metadataservice/pkg/model"
 	"github.com/ochamekan/ms/movieservice/internal/controller/movie"
+	"github.com/ochamekan/ms/movieservice/pkg/model"
 	"github.com/ochamekan/ms/pkg/logging"
 	"github.com/ochamekan/ms/pkg/metrics"
 	"go.uber.org/zap"
@@ -15,6 +16,18 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+const (
+	maxBatchSize    = 100
+	maxPageSize     = 50
+	defaultPageSize = 20
+
+	maxSimilarLimit     = 50
+	defaultSimilarLimit = 10
+
+	minRating = 1
+	maxRating = 5
+)
+
 type Handler struct {
 	gen.UnimplementedMovieServiceServer
 	ctrl    *movie.Controller
@@ -45,6 +58,10 @@ func (h *Handler) GetMovieDetails(ctx context.Context, req *gen.GetMovieDetailsR
 		h.metrics.IncMovieGetTotalCount(metrics.ErrorOutcome)
 		logger.Error("Failed to get movie details", zap.Error(err))
 		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil && errorCode(err) == codes.DeadlineExceeded {
+		h.metrics.IncMovieGetTotalCount(metrics.DeadlineExceededOutcome)
+		logger.Error("Timed out getting movie details", zap.Error(err))
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
 	} else if err != nil {
 		h.metrics.IncMovieGetTotalCount(metrics.ErrorOutcome)
 		logger.Error("Failed to get movie details", zap.Error(err))
@@ -55,10 +72,142 @@ func (h *Handler) GetMovieDetails(ctx context.Context, req *gen.GetMovieDetailsR
 	h.metrics.IncMovieGetTotalCount(metrics.SuccessOutcome)
 	h.metrics.IncMoviePopularityCount(m.Metadata.Title)
 	logger.Info("Successfully retrieved movie details")
-	return &gen.GetMovieDetailsResponse{
-		MovieDetails: &gen.MovieDetails{
-			Metadata: model.MetadataToProto(&m.Metadata),
-			Rating:   m.Rating,
-		},
-	}, nil
+	return &gen.GetMovieDetailsResponse{MovieDetails: model.MovieDetailsToProto(m)}, nil
+}
+
+func (h *Handler) BatchGetMovieDetails(ctx context.Context, req *gen.BatchGetMovieDetailsRequest) (*gen.BatchGetMovieDetailsResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "BatchGetMovieDetails"))
+	if req == nil || len(req.MovieIds) == 0 || len(req.MovieIds) > maxBatchSize {
+		h.metrics.IncMovieBatchGetTotalCount(metrics.WarningOutcome)
+		logger.Warn("nil request or incorrect number of movie ids")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or number of movie ids not in [1, %d]", maxBatchSize)
+	}
+
+	ids := make([]int, len(req.MovieIds))
+	for i, id := range req.MovieIds {
+		if id <= 0 {
+			h.metrics.IncMovieBatchGetTotalCount(metrics.WarningOutcome)
+			logger.Warn("incorrect movie id", zap.Int32("movie_id", id))
+			return nil, status.Errorf(codes.InvalidArgument, "incorrect movie id %d", id)
+		}
+		ids[i] = int(id)
+	}
+
+	logger.Info("Getting movie details in batch")
+	results, err := h.ctrl.BatchGet(ctx, ids)
+	if err != nil && errorCode(err) == codes.DeadlineExceeded {
+		h.metrics.IncMovieBatchGetTotalCount(metrics.DeadlineExceededOutcome)
+		logger.Error("Timed out getting movie details", zap.Error(err))
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	} else if err != nil {
+		h.metrics.IncMovieBatchGetTotalCount(metrics.ErrorOutcome)
+		logger.Error("Failed to get movie details", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &gen.BatchGetMovieDetailsResponse{Results: make([]*gen.MovieDetailsResult, len(results))}
+	for i, r := range results {
+		res.Results[i] = &gen.MovieDetailsResult{MovieId: int32(r.MovieID), NotFound: r.Details == nil}
+		if r.Details != nil {
+			res.Results[i].MovieDetails = model.MovieDetailsToProto(r.Details)
+		}
+	}
+
+	h.metrics.IncMovieBatchGetTotalCount(metrics.SuccessOutcome)
+	logger.Info("Successfully retrieved movie details in batch")
+	return res, nil
+}
+
+func (h *Handler) ListMovies(ctx context.Context, req *gen.ListMoviesRequest) (*gen.ListMoviesResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "ListMovies"))
+	if req == nil || req.Page < 0 || req.PageSize < 0 || req.PageSize > maxPageSize {
+		logger.Warn("nil request or incorrect page or page size")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or negative page or page size not in [0, %d]", maxPageSize)
+	}
+
+	page, pageSize := int(req.Page), int(req.PageSize)
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	// The offset is passed downstream as an int32.
+	if (page-1)*pageSize > math.MaxInt32 {
+		logger.Warn("page out of range")
+		return nil, status.Errorf(codes.InvalidArgument, "page %d out of range for page size %d", page, pageSize)
+	}
+
+	logger.Info("Listing movies")
+	movies, nextPage, err := h.ctrl.List(ctx, page, pageSize, model.SortFromProto(req.Sort))
+	if err != nil {
+		logger.Error("Failed to list movies", zap.Error(err))
+		return nil, status.Error(errorCode(err), err.Error())
+	}
+
+	res := &gen.ListMoviesResponse{Movies: make([]*gen.MovieDetails, len(movies)), NextPage: int32(nextPage)}
+	for i, m := range movies {
+		res.Movies[i] = model.MovieDetailsToProto(m)
+	}
+
+	logger.Info("Successfully listed movies")
+	return res, nil
+}
+
+func (h *Handler) PutRating(ctx context.Context, req *gen.PutRatingRequest) (*gen.PutRatingResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "PutRating"))
+	if req == nil || req.MovieId <= 0 || req.Rating < minRating || req.Rating > maxRating {
+		logger.Warn("nil request or incorrect movie id or rating")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or incorrect movie id or rating not in [%d, %d]", minRating, maxRating)
+	}
+
+	logger.Info("Adding rating")
+	if err := h.ctrl.PutRating(ctx, int(req.MovieId), int(req.Rating)); err != nil {
+		logger.Error("Failed to add rating", zap.Error(err))
+		return nil, status.Error(errorCode(err), err.Error())
+	}
+
+	logger.Info("Rating successfully added")
+	return &gen.PutRatingResponse{}, nil
+}
+
+func (h *Handler) GetSimilarMovies(ctx context.Context, req *gen.GetSimilarMoviesRequest) (*gen.GetSimilarMoviesResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "GetSimilarMovies"))
+	if req == nil || req.MovieId <= 0 || req.Limit < 0 || req.Limit > maxSimilarLimit {
+		logger.Warn("nil request or incorrect movie id or limit")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or incorrect movie id or limit not in [0, %d]", maxSimilarLimit)
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultSimilarLimit
+	}
+
+	logger.Info("Getting similar movies")
+	movies, err := h.ctrl.GetSimilar(ctx, int(req.MovieId), limit)
+	if err != nil && errors.Is(err, movie.ErrNotFound) {
+		logger.Warn("Failed to get similar movies", zap.Error(err))
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		logger.Error("Failed to get similar movies", zap.Error(err))
+		return nil, status.Error(errorCode(err), err.Error())
+	}
+
+	res := &gen.GetSimilarMoviesResponse{Movies: make([]*gen.SimilarMovie, len(movies))}
+	for i, m := range movies {
+		res.Movies[i] = &gen.SimilarMovie{MovieDetails: model.MovieDetailsToProto(&m.Details), Score: m.Score}
+	}
+
+	logger.Info("Successfully retrieved similar movies")
+	return res, nil
+}
+
+// errorCode returns the status code for an unexpected controller error:
+// DeadlineExceeded if the request or a downstream call ran out of time,
+// Internal otherwise.
+func errorCode(err error) codes.Code {
+	if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
 }
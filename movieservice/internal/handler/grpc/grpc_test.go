@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/ochamekan/ms/gen"
+	"github.com/ochamekan/ms/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListMoviesRejectsInvalidPages(t *testing.T) {
+	h := New(nil, zap.NewNop(), metrics.New(prometheus.NewRegistry()))
+
+	tests := []struct {
+		name string
+		req  *gen.ListMoviesRequest
+	}{
+		{"nil request", nil},
+		{"negative page", &gen.ListMoviesRequest{Page: -1}},
+		{"negative page size", &gen.ListMoviesRequest{PageSize: -1}},
+		{"page size over the limit", &gen.ListMoviesRequest{PageSize: maxPageSize + 1}},
+		{"offset over int32", &gen.ListMoviesRequest{Page: math.MaxInt32, PageSize: maxPageSize}},
+		{"offset over int32 with the default page size", &gen.ListMoviesRequest{Page: math.MaxInt32 / defaultPageSize * 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := h.ListMovies(context.Background(), tt.req); status.Code(err) != codes.InvalidArgument {
+				t.Errorf("got %v, want %v", err, codes.InvalidArgument)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ochamekan/ms/gen"
+	"github.com/ochamekan/ms/movieservice/internal/ratelimit"
+	"github.com/ochamekan/ms/pkg/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// forwardedHeaders are copied from HTTP requests into outgoing gRPC metadata
+// so that interceptors can identify REST clients the same way as gRPC ones.
+var forwardedHeaders = []string{"authorization", "x-api-key"}
+
+var marshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// Handler translates REST requests into MovieService gRPC calls.
+type Handler struct {
+	client gen.MovieServiceClient
+	logger *zap.Logger
+}
+
+func New(client gen.MovieServiceClient, logger *zap.Logger) *Handler {
+	return &Handler{client: client, logger: logger.With(zap.String(logging.FieldComponent, "movie http handler"))}
+}
+
+// Routes returns the REST routes served by the handler.
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/movies", h.listMovies)
+	mux.HandleFunc("GET /v1/movies/{id}", h.getMovieDetails)
+	mux.HandleFunc("GET /v1/movies/{id}/similar", h.getSimilarMovies)
+	mux.HandleFunc("POST /v1/movies/{id}/ratings", h.putRating)
+	return mux
+}
+
+func (h *Handler) getMovieDetails(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt32(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, status.Errorf(codes.InvalidArgument, "incorrect movie id %q", r.PathValue("id")))
+		return
+	}
+
+	resp, err := h.client.GetMovieDetails(h.outgoingContext(r), &gen.GetMovieDetailsRequest{MovieId: id})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeProto(w, http.StatusOK, resp.MovieDetails)
+}
+
+// listMovies serves both batch lookups (?ids=1,2,3) and paged listing
+// (?page=1&page_size=20&sort=rating).
+func (h *Handler) listMovies(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if ids := q.Get("ids"); ids != "" {
+		req := &gen.BatchGetMovieDetailsRequest{}
+		for _, s := range strings.Split(ids, ",") {
+			id, err := parseInt32(strings.TrimSpace(s))
+			if err != nil {
+				h.writeError(w, status.Errorf(codes.InvalidArgument, "incorrect movie id %q", s))
+				return
+			}
+			req.MovieIds = append(req.MovieIds, id)
+		}
+
+		resp, err := h.client.BatchGetMovieDetails(h.outgoingContext(r), req)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+
+		h.writeProto(w, http.StatusOK, resp)
+		return
+	}
+
+	req := &gen.ListMoviesRequest{}
+	for name, dst := range map[string]*int32{"page": &req.Page, "page_size": &req.PageSize} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := parseInt32(v)
+		if err != nil {
+			h.writeError(w, status.Errorf(codes.InvalidArgument, "incorrect %s %q", name, v))
+			return
+		}
+		*dst = n
+	}
+
+	switch sort := q.Get("sort"); sort {
+	case "":
+	case "rating":
+		req.Sort = gen.MovieSort_MOVIE_SORT_RATING
+	case "year":
+		req.Sort = gen.MovieSort_MOVIE_SORT_YEAR
+	default:
+		h.writeError(w, status.Errorf(codes.InvalidArgument, "unknown sort %q, expected rating or year", sort))
+		return
+	}
+
+	resp, err := h.client.ListMovies(h.outgoingContext(r), req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeProto(w, http.StatusOK, resp)
+}
+
+func (h *Handler) getSimilarMovies(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt32(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, status.Errorf(codes.InvalidArgument, "incorrect movie id %q", r.PathValue("id")))
+		return
+	}
+
+	req := &gen.GetSimilarMoviesRequest{MovieId: id}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := parseInt32(v)
+		if err != nil {
+			h.writeError(w, status.Errorf(codes.InvalidArgument, "incorrect limit %q", v))
+			return
+		}
+		req.Limit = limit
+	}
+
+	resp, err := h.client.GetSimilarMovies(h.outgoingContext(r), req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeProto(w, http.StatusOK, resp)
+}
+
+func (h *Handler) putRating(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt32(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, status.Errorf(codes.InvalidArgument, "incorrect movie id %q", r.PathValue("id")))
+		return
+	}
+
+	var body struct {
+		Rating int32 `json:"rating"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.writeError(w, status.Errorf(codes.InvalidArgument, "malformed request body: %v", err))
+		return
+	}
+
+	if _, err := h.client.PutRating(h.outgoingContext(r), &gen.PutRatingRequest{MovieId: id, Rating: body.Rating}); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Intercepted serves next behind the given gRPC server interceptors, such
+// as the load shedder and rate limiter, as if its requests were calls to
+// method. It is meant for endpoints that do not call the gRPC server, so
+// that they are guarded the same way. Rejected requests get the error
+// response of the REST API.
+func (h *Handler) Intercepted(method string, next http.Handler, interceptors ...grpc.UnaryServerInterceptor) http.Handler {
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := metadata.MD{}
+		for _, name := range forwardedHeaders {
+			if v := r.Header.Get(name); v != "" {
+				md.Set(name, v)
+			}
+		}
+		ctx := metadata.NewIncomingContext(r.Context(), md)
+		if addr, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: net.TCPAddrFromAddrPort(addr)})
+		}
+
+		handler := func(context.Context, any) (any, error) {
+			next.ServeHTTP(w, r)
+			return nil, nil
+		}
+		for _, interceptor := range slices.Backward(interceptors) {
+			inner := handler
+			handler = func(ctx context.Context, req any) (any, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+
+		// The handler writes its own errors, so only rejections are left.
+		if _, err := handler(ctx, nil); err != nil {
+			h.writeError(w, err)
+		}
+	})
+}
+
+// parseInt32 parses a decimal number, rejecting ones that do not fit the
+// int32 fields of requests instead of wrapping them.
+func parseInt32(s string) (int32, error) {
+	n, err := strconv.ParseInt(s, 10, 32)
+	return int32(n), err
+}
+
+func (h *Handler) outgoingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for _, name := range forwardedHeaders {
+		if v := r.Header.Get(name); v != "" {
+			md.Set(name, v)
+		}
+	}
+	md.Set("x-forwarded-for", r.RemoteAddr)
+
+	return metadata.NewOutgoingContext(r.Context(), md)
+}
+
+func (h *Handler) writeProto(w http.ResponseWriter, code int, m proto.Message) {
+	b, err := marshaler.Marshal(m)
+	if err != nil {
+		h.writeError(w, status.Error(codes.Internal, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+type errorBody struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	code := HTTPStatusFromCode(s.Code())
+	if code >= http.StatusInternalServerError {
+		h.logger.Error("Request failed", zap.Error(err))
+	}
+
+	var body errorBody
+	body.Error.Code = s.Code().String()
+	body.Error.Message = s.Message()
+
+	if d, ok := ratelimit.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// HTTPStatusFromCode maps a gRPC status code to the matching HTTP status code.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
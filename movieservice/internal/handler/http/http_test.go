@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ochamekan/ms/movieservice/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+func TestInterceptedLimitsByClient(t *testing.T) {
+	config := &ratelimit.Config{Default: ratelimit.Limit{Rate: 1, Burst: 1}}
+	limit := ratelimit.UnaryServerInterceptor(ratelimit.NewLocalLimiter(), config, zap.NewNop())
+
+	served := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	})
+	h := New(nil, zap.NewNop()).Intercepted("/graphql", next, limit)
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("x-api-key", "unlisted")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("10.0.0.1:5000"); w.Code != http.StatusOK {
+		t.Fatalf("first request: got %d", w.Code)
+	}
+	w := serve("10.0.0.1:5001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: got %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("no Retry-After header")
+	}
+	if w := serve("10.0.0.2:5000"); w.Code != http.StatusOK {
+		t.Errorf("request from another address: got %d", w.Code)
+	}
+	if served != 2 {
+		t.Errorf("served requests: got %d, want 2", served)
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// DefaultLimit applies to every client and method without a configured limit.
+var DefaultLimit = Limit{Rate: 100, Burst: 100}
+
+// Config holds the limits of each client and method, e.g.
+//
+//	default: {rate: 100, burst: 100}
+//	methods:
+//	  /MovieService/PutRating: {rate: 5, burst: 10}
+//	clients:
+//	  key:partner-api-key: {rate: 500, burst: 500}
+//
+// Clients are given by their ClientID. A client limit applies to each of
+// its methods and takes precedence over a method limit. Clients without
+// one are limited by their address.
+type Config struct {
+	Default Limit            `yaml:"default"`
+	Methods map[string]Limit `yaml:"methods"`
+	Clients map[string]Limit `yaml:"clients"`
+}
+
+// LoadConfig reads the limits from a YAML file. A missing file gives
+// DefaultLimit for everything.
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{Default: DefaultLimit}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, config); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for name, l := range config.all() {
+		if l.Rate <= 0 || l.Burst <= 0 {
+			return nil, fmt.Errorf("parse %s: %s limit must have a positive rate and burst", path, name)
+		}
+	}
+
+	return config, nil
+}
+
+func (c *Config) limit(client string, method string) Limit {
+	if l, ok := c.Clients[client]; ok {
+		return l
+	}
+	if l, ok := c.Methods[method]; ok {
+		return l
+	}
+	return c.Default
+}
+
+func (c *Config) all() map[string]Limit {
+	res := map[string]Limit{"default": c.Default}
+	for m, l := range c.Methods {
+		res[m] = l
+	}
+	for client, l := range c.Clients {
+		res[client] = l
+	}
+	return res
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// idleTimeout is how long the bucket of a key is kept without calls. A
+	// bucket idle for that long is full again, so dropping it changes
+	// nothing.
+	idleTimeout = 10 * time.Minute
+	// maxBuckets bounds the number of buckets kept. Past it, the least
+	// recently used bucket is dropped, and full again once it is next used.
+	maxBuckets = 100000
+)
+
+// LocalLimiter keeps a token bucket per key in memory.
+type LocalLimiter struct {
+	mu      sync.Mutex
+	buckets *expirable.LRU[string, *bucket]
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	limit   Limit
+}
+
+func NewLocalLimiter() *LocalLimiter {
+	return &LocalLimiter{buckets: expirable.NewLRU[string, *bucket](maxBuckets, nil, idleTimeout)}
+}
+
+func (l *LocalLimiter) Allow(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets.Get(key)
+	if !ok || b.limit != limit {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst), limit: limit}
+	}
+	// Adding it again restarts its idle timeout.
+	l.buckets.Add(key, b)
+
+	r := b.limiter.ReserveN(now, 1)
+	if d := r.DelayFrom(now); d > 0 {
+		r.CancelAt(now)
+		return false, d, nil
+	}
+	return true, 0, nil
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocalLimiter(t *testing.T) {
+	ctx := context.Background()
+	l := NewLocalLimiter()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := range limit.Burst {
+		if ok, _, err := l.Allow(ctx, "a", limit); err != nil || !ok {
+			t.Fatalf("call %d within the burst: got %v, %v", i, ok, err)
+		}
+	}
+
+	ok, retryAfter, err := l.Allow(ctx, "a", limit)
+	if err != nil || ok {
+		t.Fatalf("call past the burst: got %v, %v", ok, err)
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("retry after: got %v, want within a second", retryAfter)
+	}
+
+	if ok, _, _ := l.Allow(ctx, "b", limit); !ok {
+		t.Error("another key shares the bucket")
+	}
+
+	// A new limit for the key starts a new bucket.
+	if ok, _, _ := l.Allow(ctx, "a", Limit{Rate: 1, Burst: 5}); !ok {
+		t.Error("changed limit kept the old bucket")
+	}
+}
+
+func TestLocalLimiterRejectedCallsTakeNoToken(t *testing.T) {
+	ctx := context.Background()
+	l := NewLocalLimiter()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	l.Allow(ctx, "a", limit)
+	_, first, _ := l.Allow(ctx, "a", limit)
+	_, second, _ := l.Allow(ctx, "a", limit)
+
+	// Had the rejected call been counted, the wait would have doubled.
+	if second > first {
+		t.Errorf("retry after a second rejection: got %v, want at most %v", second, first)
+	}
+}
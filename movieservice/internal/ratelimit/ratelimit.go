@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/logging"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Limiter decides whether calls identified by a key fit in a limit.
+type Limiter interface {
+	// Allow takes a call under key from its limit. If the limit is used up
+	// it returns false and how long until the next call would be allowed.
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Limit is a token bucket refilled with Rate calls per second and holding
+// at most Burst calls.
+type Limit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// UnaryServerInterceptor returns a server interceptor that limits calls per
+// client and method. Rejected calls fail with codes.ResourceExhausted and a
+// RetryInfo detail telling when to retry. If the limiter fails, calls are
+// let through.
+//
+// API keys and token subjects are not verified, so a caller could claim a
+// new one for every call to get a fresh bucket. Only the ones with a limit
+// in config get a bucket of their own, the others share the bucket of the
+// caller's address.
+func UnaryServerInterceptor(limiter Limiter, config *Config, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	logger = logger.With(zap.String(logging.FieldComponent, "rate limiter"))
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		client := ClientID(ctx)
+		if _, ok := config.Clients[client]; !ok {
+			client = PeerID(ctx)
+		}
+		ok, retryAfter, err := limiter.Allow(ctx, info.FullMethod+"|"+client, config.limit(client, info.FullMethod))
+		if err != nil {
+			logger.Error("Failed to check rate limit", zap.String(logging.FieldEndpoint, info.FullMethod), zap.Error(err))
+			return handler(ctx, req)
+		}
+		if !ok {
+			return nil, exhausted(info.FullMethod, retryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func exhausted(method string, retryAfter time.Duration) error {
+	s := status.Newf(codes.ResourceExhausted, "%s is rate limited, retry in %s", method, retryAfter.Round(time.Millisecond))
+	if d, err := s.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		s = d
+	}
+	return s.Err()
+}
+
+// RetryAfter returns the retry delay attached to a rate limited call's
+// error, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// ClientID identifies the caller by, in order of preference, its API key,
+// the subject of its bearer token or its address (see PeerID).
+//
+// Credentials are not verified here, the identity only keeps one client
+// from using up the limits of others.
+func ClientID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if keys := md.Get("x-api-key"); len(keys) > 0 && keys[0] != "" {
+		return "key:" + keys[0]
+	}
+	if auth := md.Get("authorization"); len(auth) > 0 {
+		if sub := bearerSubject(auth[0]); sub != "" {
+			return "sub:" + sub
+		}
+	}
+	return PeerID(ctx)
+}
+
+// PeerID identifies the caller by its address. The REST gateway calls over
+// loopback, so the address it forwards is trusted in that case.
+func PeerID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if fwd := md.Get("x-forwarded-for"); len(fwd) > 0 {
+			if h, _, err := net.SplitHostPort(fwd[0]); err == nil {
+				return "addr:" + h
+			}
+			return "addr:" + fwd[0]
+		}
+	}
+	return "addr:" + host
+}
+
+// bearerSubject returns the "sub" claim of a JWT bearer token.
+func bearerSubject(auth string) string {
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims struct {
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Sub
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func bearer(sub string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + sub + `"}`))
+	return "Bearer e30." + payload + ".sig"
+}
+
+// incoming returns a context of a call from addr with the given metadata.
+func incoming(addr string, kv ...string) context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+	if addr == "" {
+		return ctx
+	}
+	tcp, _ := net.ResolveTCPAddr("tcp", addr)
+	return peer.NewContext(ctx, &peer.Peer{Addr: tcp})
+}
+
+func TestClientID(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"api key", incoming("10.0.0.1:5000", "x-api-key", "k1", "authorization", bearer("alice")), "key:k1"},
+		{"bearer subject", incoming("10.0.0.1:5000", "authorization", bearer("alice")), "sub:alice"},
+		{"malformed token", incoming("10.0.0.1:5000", "authorization", "Bearer nope"), "addr:10.0.0.1"},
+		{"address", incoming("10.0.0.1:5000"), "addr:10.0.0.1"},
+		{"forwarded over loopback", incoming("127.0.0.1:5000", "x-forwarded-for", "10.0.0.2:6000"), "addr:10.0.0.2"},
+		{"forwarded without port", incoming("[::1]:5000", "x-forwarded-for", "10.0.0.2"), "addr:10.0.0.2"},
+		{"forwarded by a remote caller", incoming("10.0.0.1:5000", "x-forwarded-for", "10.0.0.2:6000"), "addr:10.0.0.1"},
+		{"no peer", incoming(""), "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientID(tt.ctx); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	config := &Config{
+		Default: Limit{Rate: 1, Burst: 1},
+		Clients: map[string]Limit{"key:partner": {Rate: 1, Burst: 1}},
+	}
+	intercept := UnaryServerInterceptor(NewLocalLimiter(), config, zap.NewNop())
+	info := &grpc.UnaryServerInfo{FullMethod: "/MovieService/GetMovieDetails"}
+	handler := func(context.Context, any) (any, error) { return nil, nil }
+
+	call := func(ctx context.Context) error {
+		_, err := intercept(ctx, nil, info, handler)
+		return err
+	}
+
+	if err := call(incoming("10.0.0.1:5000", "x-api-key", "minted-1")); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	// Keys without a configured limit share the bucket of their address.
+	err := call(incoming("10.0.0.1:5000", "x-api-key", "minted-2"))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("call with a fresh key from the same address: got %v, want %s", err, codes.ResourceExhausted)
+	}
+	if d, ok := RetryAfter(err); !ok || d <= 0 {
+		t.Errorf("retry after: got %v, %v", d, ok)
+	}
+
+	if err := call(incoming("10.0.0.1:5000", "x-api-key", "partner")); err != nil {
+		t.Errorf("call with a configured key: %v", err)
+	}
+	if err := call(incoming("10.0.0.2:5000", "x-api-key", "minted-1")); err != nil {
+		t.Errorf("call from another address: %v", err)
+	}
+}
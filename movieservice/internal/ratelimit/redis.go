@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/logging"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// redisCooldown is how long the fallback is used after Redis fails, before
+// Redis is tried again.
+const redisCooldown = 1 * time.Second
+
+// gcra implements the generic cell rate algorithm. The key holds the
+// theoretical arrival time (TAT) of the next call in microseconds of the
+// Redis clock, so instances with skewed clocks share one limit.
+//
+// KEYS[1] - bucket key
+// ARGV[1] - emission interval, microseconds between calls at the rate
+// ARGV[2] - burst
+//
+// Returns {allowed, microseconds until the next call would be allowed}.
+var gcra = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+tat = math.max(tat, now)
+
+local new_tat = tat + interval
+local allow_at = new_tat - interval * burst
+if now < allow_at then
+	return {0, math.ceil(allow_at - now)}
+end
+
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, 0}
+`)
+
+// RedisLimiter shares limits between all instances through Redis. While
+// Redis is unavailable calls are limited by the fallback instead, which
+// only sees the calls to this instance.
+type RedisLimiter struct {
+	client   *redis.Client
+	fallback Limiter
+	logger   *zap.Logger
+
+	mu          sync.Mutex
+	failedUntil time.Time
+}
+
+func NewRedisLimiter(client *redis.Client, fallback Limiter, logger *zap.Logger) *RedisLimiter {
+	return &RedisLimiter{client: client, fallback: fallback, logger: logger.With(zap.String(logging.FieldComponent, "redis rate limiter"))}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if l.failing() {
+		return l.fallback.Allow(ctx, key, limit)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	interval := time.Duration(float64(time.Second) / limit.Rate)
+
+	res, err := gcra.Run(ctx, l.client, []string{"ratelimit:" + hex.EncodeToString(sum[:])}, interval.Microseconds(), limit.Burst).Int64Slice()
+	if err != nil && ctx.Err() != nil {
+		return false, 0, err
+	} else if err != nil {
+		l.fail(err)
+		return l.fallback.Allow(ctx, key, limit)
+	}
+	l.recover()
+
+	return res[0] == 1, time.Duration(res[1]) * time.Microsecond, nil
+}
+
+func (l *RedisLimiter) failing() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().Before(l.failedUntil)
+}
+
+func (l *RedisLimiter) fail(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.failedUntil.IsZero() {
+		l.logger.Warn("Redis is unavailable, limiting calls per instance", zap.Error(err))
+	}
+	l.failedUntil = time.Now().Add(redisCooldown)
+}
+
+func (l *RedisLimiter) recover() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.failedUntil.IsZero() {
+		l.logger.Info("Redis is available again, limiting calls across instances")
+		l.failedUntil = time.Time{}
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// countingLimiter allows every call and counts them.
+type countingLimiter struct {
+	calls int
+}
+
+func (l *countingLimiter) Allow(context.Context, string, Limit) (bool, time.Duration, error) {
+	l.calls++
+	return true, 0, nil
+}
+
+func newRedisLimiter(t *testing.T) (*RedisLimiter, *miniredis.Miniredis, *countingLimiter) {
+	t.Helper()
+
+	m := miniredis.RunT(t)
+	m.SetTime(time.Unix(1700000000, 0))
+	client := redis.NewClient(&redis.Options{Addr: m.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	fallback := &countingLimiter{}
+	return NewRedisLimiter(client, fallback, zap.NewNop()), m, fallback
+}
+
+func TestRedisLimiterGCRA(t *testing.T) {
+	ctx := context.Background()
+	l, m, _ := newRedisLimiter(t)
+	limit := Limit{Rate: 10, Burst: 3}
+
+	for i := range limit.Burst {
+		if ok, _, err := l.Allow(ctx, "a", limit); err != nil || !ok {
+			t.Fatalf("call %d within the burst: got %v, %v", i, ok, err)
+		}
+	}
+
+	ok, retryAfter, err := l.Allow(ctx, "a", limit)
+	if err != nil || ok {
+		t.Fatalf("call past the burst: got %v, %v", ok, err)
+	}
+	if retryAfter != 100*time.Millisecond {
+		t.Errorf("retry after: got %v, want the 100ms emission interval", retryAfter)
+	}
+
+	if ok, _, _ := l.Allow(ctx, "b", limit); !ok {
+		t.Error("another key shares the bucket")
+	}
+
+	// Once the emission interval has passed, one more call fits.
+	m.SetTime(time.Unix(1700000000, 0).Add(100 * time.Millisecond))
+	if ok, _, _ := l.Allow(ctx, "a", limit); !ok {
+		t.Error("call after the emission interval was rejected")
+	}
+	if ok, _, _ := l.Allow(ctx, "a", limit); ok {
+		t.Error("second call after the emission interval was allowed")
+	}
+
+	// The key lives only as long as the bucket takes to fill up again.
+	sum := sha256.Sum256([]byte("a"))
+	if ttl := m.TTL("ratelimit:" + hex.EncodeToString(sum[:])); ttl <= 0 || ttl > 300*time.Millisecond {
+		t.Errorf("key ttl: got %v, want at most 300ms", ttl)
+	}
+}
+
+func TestRedisLimiterFallback(t *testing.T) {
+	ctx := context.Background()
+	l, m, fallback := newRedisLimiter(t)
+	limit := Limit{Rate: 10, Burst: 1}
+
+	m.Close()
+	if ok, _, err := l.Allow(ctx, "a", limit); err != nil || !ok {
+		t.Fatalf("call while Redis is down: got %v, %v", ok, err)
+	}
+	if fallback.calls != 1 {
+		t.Fatalf("fallback calls: got %d, want 1", fallback.calls)
+	}
+
+	// Redis is not tried again during the cooldown.
+	l.Allow(ctx, "a", limit)
+	if fallback.calls != 2 || !l.failing() {
+		t.Errorf("fallback calls during the cooldown: got %d, want 2", fallback.calls)
+	}
+}
+
+func TestRedisLimiterCancelled(t *testing.T) {
+	l, _, fallback := newRedisLimiter(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := l.Allow(ctx, "a", Limit{Rate: 10, Burst: 1}); err == nil {
+		t.Fatal("got nil error for a cancelled call")
+	}
+	if fallback.calls != 0 || l.failing() {
+		t.Error("a cancelled call was taken for a Redis failure")
+	}
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/ochamekan/ms/gen"
+	"github.com/ochamekan/ms/metadataservice/pkg/model"
+)
+
+// MovieDetailsToProto converts a MovieDetails struct into generated proto counterpart.
+func MovieDetailsToProto(d *MovieDetails) *gen.MovieDetails {
+	return &gen.MovieDetails{
+		Metadata: model.MetadataToProto(&d.Metadata),
+		Rating:   d.Rating,
+		Stale:    d.Stale,
+	}
+}
+
+// SortFromProto converts a generated proto sort into a Sort.
+func SortFromProto(s gen.MovieSort) Sort {
+	switch s {
+	case gen.MovieSort_MOVIE_SORT_RATING:
+		return SortRating
+	case gen.MovieSort_MOVIE_SORT_YEAR:
+		return SortYear
+	default:
+		return SortDefault
+	}
+}
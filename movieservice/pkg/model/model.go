@@ -1,8 +1,48 @@
 package model
 
-import "github.com/ochamekan/ms/metadataservice/pkg/model"
+import (
+	"slices"
+
+	"github.com/ochamekan/ms/metadataservice/pkg/model"
+)
 
 type MovieDetails struct {
 	Rating   *float64       `json:"rating,omitempty"`
 	Metadata model.Metadata `json:"metadata"`
+	// Stale is set when the metadata could not be refreshed from the
+	// metadata service and may be out of date.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// Clone returns a copy of d that shares no state with it.
+func (d MovieDetails) Clone() MovieDetails {
+	if d.Rating != nil {
+		rating := *d.Rating
+		d.Rating = &rating
+	}
+	d.Metadata.Tags = slices.Clone(d.Metadata.Tags)
+	return d
+}
+
+// MovieDetailsResult is the outcome of a batch lookup for a single movie id.
+// Details is nil when the movie was not found.
+type MovieDetailsResult struct {
+	MovieID int           `json:"movie_id"`
+	Details *MovieDetails `json:"details,omitempty"`
+}
+
+// Sort defines how listed movies are ordered.
+type Sort int
+
+const (
+	SortDefault Sort = iota
+	SortRating
+	SortYear
+)
+
+// SimilarMovie is a movie recommended for another one, with a similarity
+// score in [0, 1].
+type SimilarMovie struct {
+	Details MovieDetails `json:"details"`
+	Score   float64      `json:"score"`
 }
@@ -4,15 +4,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	consul "github.com/hashicorp/consul/api"
 	"github.com/ochamekan/ms/pkg/discovery"
 )
 
+const (
+	// blockingWait bounds how long a single blocking query waits for changes.
+	blockingWait = 5 * time.Minute
+
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+// Registry is a discovery.Registry backed by a Consul agent. Healthy
+// instances of each looked up service are cached locally and kept up to
+// date with blocking queries, so lookups are served from memory and keep
+// returning the last known instances while Consul is unreachable.
 type Registry struct {
 	client *consul.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	services map[string]*serviceWatch
 }
 
 func NewRegistry(addr string) (*Registry, error) {
@@ -24,11 +45,17 @@ func NewRegistry(addr string) (*Registry, error) {
 		return nil, err
 	}
 
-	return &Registry{client: client}, nil
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Registry{client: client, ctx: ctx, cancel: cancel, services: make(map[string]*serviceWatch)}, nil
+}
+
+// Close stops all background watches.
+func (r *Registry) Close() {
+	r.cancel()
 }
 
-func (r *Registry) Register(ctx context.Context, instanceID string, serviceName string, hostPort string) error {
-	parts := strings.Split(hostPort, ":")
+func (r *Registry) Register(ctx context.Context, instance discovery.Instance) error {
+	parts := strings.Split(instance.Address, ":")
 	if len(parts) != 2 {
 		return errors.New("hostPort must be in a form of <host>:<port>, example: localhost:8081")
 	}
@@ -40,10 +67,17 @@ func (r *Registry) Register(ctx context.Context, instanceID string, serviceName
 
 	return r.client.Agent().ServiceRegister(&consul.AgentServiceRegistration{
 		Address: parts[0],
-		ID:      instanceID,
-		Name:    serviceName,
+		ID:      instance.ID,
+		Name:    instance.Service,
 		Port:    port,
-		Check:   &consul.AgentServiceCheck{CheckID: instanceID, TTL: "5s"},
+		Tags:    instance.Tags,
+		Meta:    instance.Meta,
+		Checks: consul.AgentServiceChecks{
+			// The heartbeat shows the process is alive, the gRPC health
+			// check that its dependencies are usable.
+			{CheckID: instance.ID, TTL: "5s"},
+			{CheckID: instance.ID + "-grpc", GRPC: instance.Address, Interval: "5s", Timeout: "2s"},
+		},
 	})
 }
 
@@ -51,21 +85,170 @@ func (r *Registry) Deregister(ctx context.Context, instanceID string, _ string)
 	return r.client.Agent().ServiceDeregister(instanceID)
 }
 
-func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string) ([]string, error) {
-	entries, _, err := r.client.Health().Service(serviceName, "", true, nil)
-	if err != nil {
-		return nil, err
-	} else if len(entries) == 0 {
-		return nil, discovery.ErrNotFound
+// ServiceAddresses returns cached healthy instances that match the filter.
+// The first lookup of a service waits for the initial query to Consul.
+func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string, filter discovery.Filter) ([]discovery.Instance, error) {
+	w := r.watch(serviceName)
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 
-	var res []string
-	for _, e := range entries {
-		res = append(res, fmt.Sprintf("%s:%d", e.Service.Address, e.Service.Port))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.loaded {
+		return nil, w.err
+	}
+	instances := filter.Apply(w.instances)
+	if len(instances) == 0 {
+		return nil, discovery.ErrNotFound
 	}
-	return res, nil
+	return instances, nil
 }
 
+// ReportHealthyState returns discovery.ErrInstanceNotFound if the agent
+// does not know the instance, e.g. after it was restarted.
 func (r *Registry) ReportHealthyState(instanceID string, _ string) error {
-	return r.client.Agent().PassTTL(instanceID, "")
+	err := r.client.Agent().PassTTL(instanceID, "")
+
+	// Older agents answer unknown checks with 500 instead of 404.
+	var statusErr consul.StatusError
+	if errors.As(err, &statusErr) && (statusErr.Code == http.StatusNotFound || strings.Contains(statusErr.Body, "Unknown check")) {
+		return discovery.ErrInstanceNotFound
+	}
+	return err
+}
+
+// Watch implements discovery.Watcher.
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []discovery.Instance, error) {
+	w := r.watch(serviceName)
+	ch := make(chan []discovery.Instance, 1)
+
+	w.mu.Lock()
+	w.subscribers[ch] = struct{}{}
+	if w.loaded {
+		ch <- slices.Clone(w.instances)
+	}
+	w.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-r.ctx.Done():
+		}
+
+		w.mu.Lock()
+		delete(w.subscribers, ch)
+		close(ch)
+		w.mu.Unlock()
+	}()
+
+	return ch, nil
+}
+
+type serviceWatch struct {
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mu          sync.Mutex
+	loaded      bool
+	instances   []discovery.Instance
+	err         error
+	subscribers map[chan []discovery.Instance]struct{}
+}
+
+// watch returns the cached state of the given service, starting a
+// background blocking query loop on first use.
+func (r *Registry) watch(serviceName string) *serviceWatch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.services[serviceName]
+	if !ok {
+		w = &serviceWatch{ready: make(chan struct{}), subscribers: make(map[chan []discovery.Instance]struct{})}
+		r.services[serviceName] = w
+		go r.run(serviceName, w)
+	}
+	return w
+}
+
+func (r *Registry) run(serviceName string, w *serviceWatch) {
+	var index uint64
+	backoff := minBackoff
+
+	for {
+		opts := (&consul.QueryOptions{WaitIndex: index, WaitTime: blockingWait}).WithContext(r.ctx)
+		entries, meta, err := r.client.Health().Service(serviceName, "", true, opts)
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			w.fail(err)
+
+			select {
+			case <-time.After(backoff):
+			case <-r.ctx.Done():
+				return
+			}
+			backoff = min(2*backoff, maxBackoff)
+			continue
+		}
+		backoff = minBackoff
+
+		// Consul may reset the index, in which case the wait starts over.
+		if meta.LastIndex < index {
+			index = 0
+		} else {
+			index = meta.LastIndex
+		}
+
+		instances := make([]discovery.Instance, 0, len(entries))
+		for _, e := range entries {
+			instances = append(instances, discovery.Instance{
+				ID:      e.Service.ID,
+				Service: e.Service.Service,
+				Address: fmt.Sprintf("%s:%d", e.Service.Address, e.Service.Port),
+				Tags:    e.Service.Tags,
+				Meta:    e.Service.Meta,
+			})
+		}
+		discovery.SortInstances(instances)
+		w.set(instances)
+	}
+}
+
+// fail records a failed query. Once instances have been loaded, errors are
+// ignored so that the last known instances keep being served.
+func (w *serviceWatch) fail(err error) {
+	w.mu.Lock()
+	if !w.loaded {
+		w.err = err
+	}
+	w.mu.Unlock()
+
+	w.readyOnce.Do(func() { close(w.ready) })
+}
+
+func (w *serviceWatch) set(instances []discovery.Instance) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.readyOnce.Do(func() { close(w.ready) })
+
+	if w.loaded && slices.EqualFunc(instances, w.instances, discovery.Instance.Equal) {
+		return
+	}
+	w.loaded, w.instances, w.err = true, instances, nil
+
+	for ch := range w.subscribers {
+		// Drop the update the subscriber has not read yet, only the latest matters.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- slices.Clone(instances)
+	}
 }
@@ -1,25 +1,113 @@
 package discovery
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"math/rand"
+	"slices"
 	"time"
 )
 
 type Registry interface {
 	// Register creates a service instance record in the registry.
-	Register(ctx context.Context, instanceID string, serviceName string, hostPort string) error
+	Register(ctx context.Context, instance Instance) error
 	// Deregister removes a service instance record from the registry.
 	Deregister(ctx context.Context, instanceID string, serviceName string) error
-	// ServiceAddresses return the list of addresses of active instances of the given service.
-	ServiceAddresses(ctx context.Context, serviceID string) ([]string, error)
+	// ServiceAddresses return the active instances of the given service that match the filter.
+	ServiceAddresses(ctx context.Context, serviceName string, filter Filter) ([]Instance, error)
 	// ReportHealthyState is a push mechanism for reporting healthy state to the registry.
 	ReportHealthyState(instanceID string, serviceName string) error
 }
 
-var ErrNotFound = errors.New("no service addresses found")
+// Watcher is implemented by registries that push changes to the set of
+// active instances instead of being polled.
+type Watcher interface {
+	// Watch returns a channel that receives the active instances of the
+	// given service whenever they change, starting with the current ones.
+	// Slow receivers only get the latest set. The channel is closed when
+	// ctx is done.
+	Watch(ctx context.Context, serviceName string) (<-chan []Instance, error)
+}
+
+// Well-known instance metadata keys.
+const (
+	MetaVersion = "version"
+	MetaZone    = "zone"
+	MetaWeight  = "weight"
+)
+
+// Instance is a registered instance of a service.
+type Instance struct {
+	ID      string
+	Service string
+	// Address is in a form of <host>:<port>.
+	Address string
+	Tags    []string
+	Meta    map[string]string
+}
+
+// Equal reports whether both instances have the same id, address, tags and metadata.
+func (i Instance) Equal(other Instance) bool {
+	if i.ID != other.ID || i.Service != other.Service || i.Address != other.Address || len(i.Meta) != len(other.Meta) {
+		return false
+	}
+	for k, v := range i.Meta {
+		if ov, ok := other.Meta[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return slices.Equal(i.Tags, other.Tags)
+}
+
+// Filter selects instances by tags and metadata. The zero Filter matches
+// every instance.
+type Filter struct {
+	// Tags all have to be present on the instance.
+	Tags []string
+	// Meta values all have to be equal to the instance's.
+	Meta map[string]string
+}
+
+// Match reports whether the instance passes the filter.
+func (f Filter) Match(i Instance) bool {
+	for _, t := range f.Tags {
+		if !slices.Contains(i.Tags, t) {
+			return false
+		}
+	}
+	for k, v := range f.Meta {
+		if iv, ok := i.Meta[k]; !ok || iv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the instances that pass the filter.
+func (f Filter) Apply(instances []Instance) []Instance {
+	var res []Instance
+	for _, i := range instances {
+		if f.Match(i) {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// SortInstances orders instances by address, then id, so that sets of
+// instances can be compared.
+func SortInstances(instances []Instance) {
+	slices.SortFunc(instances, func(a, b Instance) int {
+		return cmp.Or(cmp.Compare(a.Address, b.Address), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+var (
+	ErrNotFound         = errors.New("no service addresses found")
+	ErrInstanceNotFound = errors.New("service instance not registered")
+)
 
 func GenerateInstanceID(serviceName string) string {
 	return fmt.Sprintf("%s-%d", serviceName, rand.New(rand.NewSource(time.Now().UnixNano())).Int())
@@ -0,0 +1,209 @@
+package dns
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/ochamekan/ms/pkg/discovery"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	queryTimeout = 2 * time.Second
+	// minTTL keeps records with a zero or tiny TTL from being looked up on
+	// every call.
+	minTTL = 1 * time.Second
+)
+
+// Registry is a discovery.Registry that resolves the instances of a service
+// from the `_grpc._tcp.<service>[.<domain>]` SRV records. Only the records
+// with the lowest priority are returned, with their weight in the "weight"
+// metadata, and results are cached for the records' TTL.
+//
+// Instances are managed in DNS, so Register, Deregister and
+// ReportHealthyState are no-ops.
+type Registry struct {
+	client *dns.Client
+	server string
+	domain string
+
+	group singleflight.Group
+	mu    sync.Mutex
+	cache map[string]entry
+}
+
+type entry struct {
+	instances []discovery.Instance
+	expires   time.Time
+}
+
+// NewRegistry returns a registry querying the DNS server at server, given as
+// host:port, or the first nameserver in /etc/resolv.conf if empty. Service
+// names are qualified with domain unless it is empty.
+func NewRegistry(server string, domain string) (*Registry, error) {
+	if server == "" {
+		config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil {
+			return nil, err
+		}
+		if len(config.Servers) == 0 {
+			return nil, errors.New("no nameserver in /etc/resolv.conf")
+		}
+		server = net.JoinHostPort(config.Servers[0], config.Port)
+	}
+
+	return &Registry{
+		client: &dns.Client{Timeout: queryTimeout},
+		server: server,
+		domain: strings.Trim(domain, "."),
+		cache:  make(map[string]entry),
+	}, nil
+}
+
+func (r *Registry) Register(ctx context.Context, instance discovery.Instance) error {
+	return nil
+}
+
+func (r *Registry) Deregister(ctx context.Context, instanceID string, serviceName string) error {
+	return nil
+}
+
+func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string, filter discovery.Filter) ([]discovery.Instance, error) {
+	instances, err := r.lookup(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	instances = filter.Apply(instances)
+	if len(instances) == 0 {
+		return nil, discovery.ErrNotFound
+	}
+	return instances, nil
+}
+
+func (r *Registry) ReportHealthyState(instanceID string, serviceName string) error {
+	return nil
+}
+
+// lookup returns the cached instances of the service, querying DNS once
+// they have expired. The last known instances are kept while the DNS server
+// is unreachable.
+func (r *Registry) lookup(ctx context.Context, serviceName string) ([]discovery.Instance, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[serviceName]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.instances, nil
+	}
+
+	ch := r.group.DoChan(serviceName, func() (any, error) {
+		instances, ttl, err := r.query(serviceName)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		r.cache[serviceName] = entry{instances, time.Now().Add(ttl)}
+		r.mu.Unlock()
+		return instances, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			if ok && !errors.Is(res.Err, discovery.ErrNotFound) {
+				return cached.instances, nil
+			}
+			return nil, res.Err
+		}
+		return res.Val.([]discovery.Instance), nil
+	}
+}
+
+func (r *Registry) query(serviceName string) ([]discovery.Instance, time.Duration, error) {
+	name := "_grpc._tcp." + serviceName
+	if r.domain != "" {
+		name += "." + r.domain
+	}
+
+	msg := new(dns.Msg).SetQuestion(dns.Fqdn(name), dns.TypeSRV)
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	resp, _, err := r.client.ExchangeContext(ctx, msg, r.server)
+	if err == nil && resp.Truncated {
+		tcp := &dns.Client{Net: "tcp", Timeout: queryTimeout}
+		resp, _, err = tcp.ExchangeContext(ctx, msg, r.server)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch resp.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeNameError:
+		return nil, 0, discovery.ErrNotFound
+	default:
+		return nil, 0, fmt.Errorf("lookup %s: %s", name, dns.RcodeToString[resp.Rcode])
+	}
+
+	var records []*dns.SRV
+	for _, rr := range resp.Answer {
+		if srv, ok := rr.(*dns.SRV); ok {
+			records = append(records, srv)
+		}
+	}
+	if len(records) == 0 {
+		return nil, 0, discovery.ErrNotFound
+	}
+
+	return instances(serviceName, records), ttl(records), nil
+}
+
+// instances returns the records with the lowest priority, which according
+// to RFC 2782 are the ones clients must use while they are reachable.
+// Records with zero weight are only used when no other is available.
+func instances(serviceName string, records []*dns.SRV) []discovery.Instance {
+	priority := slices.MinFunc(records, func(a, b *dns.SRV) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	}).Priority
+
+	records = slices.DeleteFunc(slices.Clone(records), func(srv *dns.SRV) bool {
+		return srv.Priority != priority
+	})
+	if slices.ContainsFunc(records, func(srv *dns.SRV) bool { return srv.Weight > 0 }) {
+		records = slices.DeleteFunc(records, func(srv *dns.SRV) bool { return srv.Weight == 0 })
+	}
+
+	res := make([]discovery.Instance, 0, len(records))
+	for _, srv := range records {
+		addr := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+		res = append(res, discovery.Instance{
+			ID:      addr,
+			Service: serviceName,
+			Address: addr,
+			Meta:    map[string]string{discovery.MetaWeight: strconv.Itoa(int(srv.Weight))},
+		})
+	}
+	discovery.SortInstances(res)
+	return res
+}
+
+func ttl(records []*dns.SRV) time.Duration {
+	ttl := slices.MinFunc(records, func(a, b *dns.SRV) int {
+		return cmp.Compare(a.Hdr.Ttl, b.Hdr.Ttl)
+	}).Hdr.Ttl
+	return max(time.Duration(ttl)*time.Second, minTTL)
+}
@@ -0,0 +1,181 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/ochamekan/ms/pkg/discovery"
+)
+
+// stubServer is a local DNS server answering SRV queries from records, and
+// with rcode for any name it has no records of.
+type stubServer struct {
+	addr    string
+	queries atomic.Int64
+
+	mu      sync.Mutex
+	records map[string][]*dns.SRV
+	rcode   int
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &stubServer{addr: pc.LocalAddr().String(), records: make(map[string][]*dns.SRV), rcode: dns.RcodeNameError}
+	started := make(chan struct{})
+	srv := &dns.Server{PacketConn: pc, Handler: s, NotifyStartedFunc: func() { close(started) }}
+	go srv.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { srv.Shutdown() })
+	return s
+}
+
+func (s *stubServer) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	s.queries.Add(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := new(dns.Msg).SetReply(req)
+	records, ok := s.records[req.Question[0].Name]
+	if !ok {
+		resp.Rcode = s.rcode
+	}
+	for _, srv := range records {
+		resp.Answer = append(resp.Answer, srv)
+	}
+	w.WriteMsg(resp)
+}
+
+func (s *stubServer) set(name string, ttl uint32, records ...[3]uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[name] = nil
+	for i, r := range records {
+		s.records[name] = append(s.records[name], &dns.SRV{
+			Hdr:      dns.RR_Header{Name: name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: ttl},
+			Priority: r[0],
+			Weight:   r[1],
+			Port:     r[2],
+			Target:   "host" + string(rune('a'+i)) + ".example.",
+		})
+	}
+}
+
+func (s *stubServer) fail(rcode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = make(map[string][]*dns.SRV)
+	s.rcode = rcode
+}
+
+func addresses(t *testing.T, r *Registry, service string) map[string]string {
+	t.Helper()
+
+	instances, err := r.ServiceAddresses(context.Background(), service, discovery.Filter{})
+	if err != nil {
+		t.Fatalf("ServiceAddresses: %v", err)
+	}
+	res := make(map[string]string, len(instances))
+	for _, i := range instances {
+		res[i.Address] = i.Meta[discovery.MetaWeight]
+	}
+	return res
+}
+
+func TestServiceAddressesPriorityAndWeight(t *testing.T) {
+	s := newStubServer(t)
+	r, err := NewRegistry(s.addr, "svc.local.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		records [][3]uint16
+		want    map[string]string
+	}{
+		{
+			name:    "lowest priority only",
+			records: [][3]uint16{{10, 1, 8082}, {10, 3, 8083}, {20, 5, 8084}},
+			want:    map[string]string{"hosta.example:8082": "1", "hostb.example:8083": "3"},
+		},
+		{
+			name:    "zero weight with others",
+			records: [][3]uint16{{10, 0, 8082}, {10, 3, 8083}},
+			want:    map[string]string{"hostb.example:8083": "3"},
+		},
+		{
+			name:    "only zero weights",
+			records: [][3]uint16{{10, 0, 8082}, {10, 0, 8083}},
+			want:    map[string]string{"hosta.example:8082": "0", "hostb.example:8083": "0"},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Each case uses its own service, so none is served from cache.
+			service := "rating" + string(rune('0'+i))
+			s.set("_grpc._tcp."+service+".svc.local.", 60, tt.records...)
+
+			got := addresses(t, r, service)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for addr, w := range tt.want {
+				if got[addr] != w {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceAddressesNotFound(t *testing.T) {
+	s := newStubServer(t)
+	r, _ := NewRegistry(s.addr, "")
+
+	if _, err := r.ServiceAddresses(context.Background(), "rating", discovery.Filter{}); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("got %v, want %v", err, discovery.ErrNotFound)
+	}
+}
+
+func TestServiceAddressesCache(t *testing.T) {
+	s := newStubServer(t)
+	r, _ := NewRegistry(s.addr, "")
+	s.set("_grpc._tcp.rating.", 1, [3]uint16{10, 1, 8082})
+
+	addresses(t, r, "rating")
+	addresses(t, r, "rating")
+	if n := s.queries.Load(); n != 1 {
+		t.Fatalf("queries within the TTL: got %d, want 1", n)
+	}
+
+	// Once expired, the last known instances are kept while the server
+	// fails, but not once it says the name is gone.
+	time.Sleep(1100 * time.Millisecond)
+	s.fail(dns.RcodeServerFailure)
+	if got := addresses(t, r, "rating"); got["hosta.example:8082"] != "1" {
+		t.Fatalf("got %v while the server fails, want the cached instance", got)
+	}
+	if n := s.queries.Load(); n != 2 {
+		t.Fatalf("queries after the TTL: got %d, want 2", n)
+	}
+
+	s.fail(dns.RcodeNameError)
+	if _, err := r.ServiceAddresses(context.Background(), "rating", discovery.Filter{}); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("got %v once the name is gone, want %v", err, discovery.ErrNotFound)
+	}
+}
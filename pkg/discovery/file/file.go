@@ -0,0 +1,170 @@
+package file
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ochamekan/ms/pkg/discovery"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// Registry is a discovery.Registry backed by a YAML or JSON file mapping
+// service names to instances, given either as an address or with tags and
+// metadata, e.g.
+//
+//	metadata:
+//	  - localhost:8081
+//	rating:
+//	  - localhost:8082
+//	  - address: localhost:9082
+//	    tags: [canary]
+//	    meta: {version: v2, zone: eu-west-1b}
+//
+// The file is reloaded whenever it changes. Instances are declared by the
+// file only, so Register, Deregister and ReportHealthyState are no-ops.
+type Registry struct {
+	path    string
+	watcher *fsnotify.Watcher
+	logger  *zap.Logger
+	done    chan struct{}
+
+	mu       sync.RWMutex
+	services map[string][]discovery.Instance
+}
+
+// NewRegistry loads the file at path and starts watching it for changes.
+func NewRegistry(path string, logger *zap.Logger) (*Registry, error) {
+	r := &Registry{path: path, logger: logger, done: make(chan struct{})}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Editors often replace the file instead of writing to it, so the
+	// directory is watched rather than the file itself.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	r.watcher = watcher
+
+	go r.watch()
+	return r, nil
+}
+
+// Close stops watching the file.
+func (r *Registry) Close() error {
+	err := r.watcher.Close()
+	<-r.done
+	return err
+}
+
+func (r *Registry) Register(ctx context.Context, instance discovery.Instance) error {
+	return nil
+}
+
+func (r *Registry) Deregister(ctx context.Context, instanceID string, serviceName string) error {
+	return nil
+}
+
+func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string, filter discovery.Filter) ([]discovery.Instance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	instances := filter.Apply(r.services[serviceName])
+	if len(instances) == 0 {
+		return nil, discovery.ErrNotFound
+	}
+	return instances, nil
+}
+
+func (r *Registry) ReportHealthyState(instanceID string, serviceName string) error {
+	return nil
+}
+
+func (r *Registry) load() error {
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+
+	// YAML is a superset of JSON, so both formats are parsed the same way.
+	var entries map[string][]entry
+	if err := yaml.Unmarshal(b, &entries); err != nil {
+		return fmt.Errorf("parse %s: %w", r.path, err)
+	}
+
+	services := make(map[string][]discovery.Instance, len(entries))
+	for name, es := range entries {
+		for _, e := range es {
+			if e.Address == "" {
+				return fmt.Errorf("parse %s: instance of %s has no address", r.path, name)
+			}
+			services[name] = append(services[name], discovery.Instance{
+				ID:      cmp.Or(e.ID, e.Address),
+				Service: name,
+				Address: e.Address,
+				Tags:    e.Tags,
+				Meta:    e.Meta,
+			})
+		}
+	}
+
+	r.mu.Lock()
+	r.services = services
+	r.mu.Unlock()
+	return nil
+}
+
+// entry is an instance in the file, either a bare address or a mapping.
+type entry struct {
+	ID      string            `yaml:"id"`
+	Address string            `yaml:"address"`
+	Tags    []string          `yaml:"tags"`
+	Meta    map[string]string `yaml:"meta"`
+}
+
+func (e *entry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&e.Address)
+	}
+
+	type plain entry
+	return node.Decode((*plain)(e))
+}
+
+func (r *Registry) watch() {
+	defer close(r.done)
+
+	for {
+		select {
+		case e, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(e.Name) != filepath.Clean(r.path) || !e.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			// A broken file keeps the last good set of instances.
+			if err := r.load(); err != nil {
+				r.logger.Error("Failed to reload service registry file", zap.String("path", r.path), zap.Error(err))
+				continue
+			}
+			r.logger.Info("Reloaded service registry file", zap.String("path", r.path))
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			r.logger.Error("Failed to watch service registry file", zap.String("path", r.path), zap.Error(err))
+		}
+	}
+}
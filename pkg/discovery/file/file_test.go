@@ -0,0 +1,170 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/discovery"
+	"go.uber.org/zap"
+)
+
+func newRegistry(t *testing.T, content string) (*Registry, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRegistry(path, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r, path
+}
+
+func TestServiceAddresses(t *testing.T) {
+	r, _ := newRegistry(t, `
+metadata:
+  - localhost:8081
+rating:
+  - localhost:8082
+  - id: rating-canary
+    address: localhost:9082
+    tags: [canary]
+    meta: {version: v2}
+`)
+
+	tests := []struct {
+		name    string
+		service string
+		filter  discovery.Filter
+		want    []discovery.Instance
+	}{
+		{
+			name:    "address",
+			service: "metadata",
+			want:    []discovery.Instance{{ID: "localhost:8081", Service: "metadata", Address: "localhost:8081"}},
+		},
+		{
+			name:    "mapping",
+			service: "rating",
+			filter:  discovery.Filter{Tags: []string{"canary"}},
+			want: []discovery.Instance{{
+				ID:      "rating-canary",
+				Service: "rating",
+				Address: "localhost:9082",
+				Tags:    []string{"canary"},
+				Meta:    map[string]string{discovery.MetaVersion: "v2"},
+			}},
+		},
+		{
+			name:    "all",
+			service: "rating",
+			want: []discovery.Instance{
+				{ID: "localhost:8082", Service: "rating", Address: "localhost:8082"},
+				{ID: "rating-canary", Service: "rating", Address: "localhost:9082", Tags: []string{"canary"}, Meta: map[string]string{discovery.MetaVersion: "v2"}},
+			},
+		},
+		{name: "unknown service", service: "movie"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.ServiceAddresses(context.Background(), tt.service, tt.filter)
+			if tt.want == nil {
+				if !errors.Is(err, discovery.ErrNotFound) {
+					t.Fatalf("got %v, %v, want %v", got, err, discovery.ErrNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ServiceAddresses: %v", err)
+			}
+			discovery.SortInstances(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	r, _ := newRegistry(t, `{"rating": ["localhost:8082", {"address": "localhost:9082"}]}`)
+
+	got, err := r.ServiceAddresses(context.Background(), "rating", discovery.Filter{})
+	if err != nil {
+		t.Fatalf("ServiceAddresses: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %v, want 2 instances", got)
+	}
+}
+
+func TestNewRegistryRejectsInvalidFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"syntax":     "rating: [",
+		"no address": "rating:\n  - tags: [canary]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "services.yaml")
+			os.WriteFile(path, []byte(content), 0o644)
+			if _, err := NewRegistry(path, zap.NewNop()); err == nil {
+				t.Fatal("NewRegistry: got nil error")
+			}
+		})
+	}
+}
+
+// waitForAddresses polls until the registry returns the given number of
+// instances of rating, as reloads happen asynchronously.
+func waitForAddresses(t *testing.T, r *Registry, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := r.ServiceAddresses(context.Background(), "rating", discovery.Filter{})
+		if len(got) == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %v, want %d instances", got, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReload(t *testing.T) {
+	r, path := newRegistry(t, "rating:\n  - localhost:8082\n")
+	waitForAddresses(t, r, 1)
+
+	if err := os.WriteFile(path, []byte("rating:\n  - localhost:8082\n  - localhost:9082\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForAddresses(t, r, 2)
+
+	// Editors often replace the file rather than writing to it.
+	tmp := path + ".tmp"
+	os.WriteFile(tmp, []byte("rating:\n  - localhost:8082\n  - localhost:9082\n  - localhost:10082\n"), 0o644)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitForAddresses(t, r, 3)
+
+	// A broken file keeps the last good set of instances. It is renamed into
+	// place, as writing it would first reload the truncated, empty file.
+	os.WriteFile(tmp, []byte("rating: ["), 0o644)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	waitForAddresses(t, r, 3)
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/discovery"
+)
+
+// Registry is an in-memory discovery.Registry for tests and single-process
+// local development. Like a Consul TTL check, an instance starts unhealthy
+// and is only returned by ServiceAddresses while its last healthy state
+// report is younger than the TTL.
+type Registry struct {
+	ttl time.Duration
+
+	mu       sync.RWMutex
+	services map[string]map[string]*instance
+}
+
+type instance struct {
+	discovery.Instance
+	lastActive time.Time
+}
+
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{ttl: ttl, services: make(map[string]map[string]*instance)}
+}
+
+func (r *Registry) Register(ctx context.Context, i discovery.Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.services[i.Service]; !ok {
+		r.services[i.Service] = make(map[string]*instance)
+	}
+	r.services[i.Service][i.ID] = &instance{Instance: i}
+	return nil
+}
+
+func (r *Registry) Deregister(ctx context.Context, instanceID string, serviceName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.services[serviceName][instanceID]; !ok {
+		return discovery.ErrInstanceNotFound
+	}
+	delete(r.services[serviceName], instanceID)
+	return nil
+}
+
+func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string, filter discovery.Filter) ([]discovery.Instance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var res []discovery.Instance
+	for _, i := range r.services[serviceName] {
+		if time.Since(i.lastActive) <= r.ttl && filter.Match(i.Instance) {
+			res = append(res, i.Instance)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, discovery.ErrNotFound
+	}
+	return res, nil
+}
+
+func (r *Registry) ReportHealthyState(instanceID string, serviceName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, ok := r.services[serviceName][instanceID]
+	if !ok {
+		return discovery.ErrInstanceNotFound
+	}
+	i.lastActive = time.Now()
+	return nil
+}
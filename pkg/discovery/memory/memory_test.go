@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/discovery"
+)
+
+func TestServiceAddressesRequiresHealthyReport(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry(time.Minute)
+
+	i := discovery.Instance{ID: "rating-1", Service: "rating", Address: "localhost:8082"}
+	if err := r.Register(ctx, i); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if _, err := r.ServiceAddresses(ctx, "rating", discovery.Filter{}); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("ServiceAddresses before a healthy report: got %v, want %v", err, discovery.ErrNotFound)
+	}
+
+	if err := r.ReportHealthyState("rating-1", "rating"); err != nil {
+		t.Fatalf("ReportHealthyState: %v", err)
+	}
+	got, err := r.ServiceAddresses(ctx, "rating", discovery.Filter{})
+	if err != nil {
+		t.Fatalf("ServiceAddresses: %v", err)
+	}
+	if len(got) != 1 || !got[0].Equal(i) {
+		t.Fatalf("ServiceAddresses: got %v, want [%v]", got, i)
+	}
+}
+
+func TestServiceAddressesExpiresAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry(10 * time.Millisecond)
+
+	r.Register(ctx, discovery.Instance{ID: "rating-1", Service: "rating", Address: "localhost:8082"})
+	r.ReportHealthyState("rating-1", "rating")
+	if _, err := r.ServiceAddresses(ctx, "rating", discovery.Filter{}); err != nil {
+		t.Fatalf("ServiceAddresses within the TTL: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, err := r.ServiceAddresses(ctx, "rating", discovery.Filter{}); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("ServiceAddresses after the TTL: got %v, want %v", err, discovery.ErrNotFound)
+	}
+
+	r.ReportHealthyState("rating-1", "rating")
+	if _, err := r.ServiceAddresses(ctx, "rating", discovery.Filter{}); err != nil {
+		t.Fatalf("ServiceAddresses after a new report: %v", err)
+	}
+}
+
+func TestServiceAddressesFilter(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry(time.Minute)
+
+	for _, i := range []discovery.Instance{
+		{ID: "rating-1", Service: "rating", Address: "localhost:8082", Meta: map[string]string{discovery.MetaVersion: "v1"}},
+		{ID: "rating-2", Service: "rating", Address: "localhost:9082", Tags: []string{"canary"}, Meta: map[string]string{discovery.MetaVersion: "v2"}},
+	} {
+		r.Register(ctx, i)
+		r.ReportHealthyState(i.ID, i.Service)
+	}
+
+	tests := []struct {
+		name   string
+		filter discovery.Filter
+		want   []string
+	}{
+		{"none", discovery.Filter{}, []string{"rating-1", "rating-2"}},
+		{"tag", discovery.Filter{Tags: []string{"canary"}}, []string{"rating-2"}},
+		{"meta", discovery.Filter{Meta: map[string]string{discovery.MetaVersion: "v1"}}, []string{"rating-1"}},
+		{"no match", discovery.Filter{Meta: map[string]string{discovery.MetaVersion: "v3"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.ServiceAddresses(ctx, "rating", tt.filter)
+			if tt.want == nil {
+				if !errors.Is(err, discovery.ErrNotFound) {
+					t.Fatalf("got %v, %v, want %v", got, err, discovery.ErrNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ServiceAddresses: %v", err)
+			}
+			discovery.SortInstances(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want ids %v", got, tt.want)
+			}
+			for i, id := range tt.want {
+				if got[i].ID != id {
+					t.Fatalf("got %v, want ids %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeregister(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry(time.Minute)
+
+	r.Register(ctx, discovery.Instance{ID: "rating-1", Service: "rating", Address: "localhost:8082"})
+	r.ReportHealthyState("rating-1", "rating")
+	if err := r.Deregister(ctx, "rating-1", "rating"); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+
+	if _, err := r.ServiceAddresses(ctx, "rating", discovery.Filter{}); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("ServiceAddresses: got %v, want %v", err, discovery.ErrNotFound)
+	}
+	if err := r.Deregister(ctx, "rating-1", "rating"); !errors.Is(err, discovery.ErrInstanceNotFound) {
+		t.Fatalf("Deregister twice: got %v, want %v", err, discovery.ErrInstanceNotFound)
+	}
+	if err := r.ReportHealthyState("rating-1", "rating"); !errors.Is(err, discovery.ErrInstanceNotFound) {
+		t.Fatalf("ReportHealthyState: got %v, want %v", err, discovery.ErrInstanceNotFound)
+	}
+}
@@ -0,0 +1,100 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ochamekan/ms/pkg/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	heartbeatInterval = 1 * time.Second
+	// maxHeartbeatBackoff bounds the delay between failed heartbeats.
+	maxHeartbeatBackoff = 10 * time.Second
+)
+
+// Registration keeps a service instance registered and reported healthy
+// until Deregister is called.
+type Registration struct {
+	registry Registry
+	instance Instance
+	logger   *zap.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// Register registers the instance and starts reporting its healthy state
+// every second. Failed reports are retried with exponential backoff, and
+// the instance is registered again if the registry has lost it.
+func Register(ctx context.Context, registry Registry, instance Instance, logger *zap.Logger) (*Registration, error) {
+	if err := registry.Register(ctx, instance); err != nil {
+		return nil, err
+	}
+
+	hctx, cancel := context.WithCancel(context.Background())
+	r := &Registration{
+		registry: registry,
+		instance: instance,
+		logger:   logger.With(zap.String(logging.FieldComponent, "registration"), zap.String("instance", instance.ID)),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go r.heartbeat(hctx)
+	return r, nil
+}
+
+// Deregister stops the heartbeat and removes the instance from the
+// registry. It should be called before the server starts draining so that
+// clients stop picking the instance. Later calls return the first result.
+func (r *Registration) Deregister(ctx context.Context) error {
+	r.once.Do(func() {
+		r.cancel()
+		<-r.done
+		r.err = r.registry.Deregister(ctx, r.instance.ID, r.instance.Service)
+	})
+	return r.err
+}
+
+func (r *Registration) heartbeat(ctx context.Context) {
+	defer close(r.done)
+
+	delay := heartbeatInterval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := r.report(ctx); err != nil {
+			delay = min(2*delay, maxHeartbeatBackoff)
+			r.logger.Error("Failed to report healthy state", zap.Duration("retry_in", delay), zap.Error(err))
+		} else {
+			delay = heartbeatInterval
+		}
+		timer.Reset(delay)
+	}
+}
+
+func (r *Registration) report(ctx context.Context) error {
+	err := r.registry.ReportHealthyState(r.instance.ID, r.instance.Service)
+	if !errors.Is(err, ErrInstanceNotFound) {
+		return err
+	}
+
+	r.logger.Warn("Instance is not known to the registry, registering again")
+	if err := r.registry.Register(ctx, r.instance); err != nil {
+		return err
+	}
+	return r.registry.ReportHealthyState(r.instance.ID, r.instance.Service)
+}
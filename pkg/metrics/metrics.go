@@ -7,8 +7,13 @@ import (
 type Metrics struct {
 	// TODO: make first 2 as one metric
 	MovieGetDetailsTotal    *prometheus.CounterVec
+	MovieBatchGetTotal      *prometheus.CounterVec
 	MovieFilmPopularity     *prometheus.CounterVec
 	MovieGetDetailsDuration prometheus.Histogram
+	MovieDetailsCache       *prometheus.CounterVec
+	CircuitBreakerState     *prometheus.GaugeVec
+	HedgedRequests          *prometheus.CounterVec
+	SimilarTruncated        prometheus.Counter
 }
 
 type RequestOutcome string
@@ -17,6 +22,29 @@ const (
 	SuccessOutcome RequestOutcome = "success"
 	ErrorOutcome   RequestOutcome = "error"
 	WarningOutcome RequestOutcome = "warning"
+	// DeadlineExceededOutcome is an error caused by the request or a
+	// downstream call running out of time.
+	DeadlineExceededOutcome RequestOutcome = "deadline_exceeded"
+)
+
+type CacheResult string
+
+const (
+	CacheHit       CacheResult = "hit"
+	CacheMiss      CacheResult = "miss"
+	CacheCoalesced CacheResult = "coalesced"
+)
+
+type HedgeResult string
+
+const (
+	// HedgeSent counts second copies of slow calls.
+	HedgeSent HedgeResult = "sent"
+	// HedgeWon counts second copies that returned first.
+	HedgeWon HedgeResult = "won"
+	// HedgeSkipped counts slow calls not hedged because the hedge budget
+	// was used up.
+	HedgeSkipped HedgeResult = "skipped"
 )
 
 func New(reg prometheus.Registerer) *Metrics {
@@ -25,6 +53,10 @@ func New(reg prometheus.Registerer) *Metrics {
 			Name: "movie_get_details_requests_total",
 			Help: "Number of requests",
 		}, []string{"outcome"}),
+		MovieBatchGetTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "movie_batch_get_details_requests_total",
+			Help: "Number of batch requests",
+		}, []string{"outcome"}),
 		MovieFilmPopularity: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "movie_popularity_count",
 			Help: "Movie popularity",
@@ -33,8 +65,24 @@ func New(reg prometheus.Registerer) *Metrics {
 			Name: "movie_get_details_duration",
 			Help: "Duration of the request",
 		}),
+		MovieDetailsCache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "movie_details_cache_requests_total",
+			Help: "Number of movie details cache lookups by result",
+		}, []string{"result"}),
+		CircuitBreakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "movie_circuit_breaker_state",
+			Help: "State of the circuit breaker of each downstream service: 0 closed, 1 half-open, 2 open",
+		}, []string{"service"}),
+		HedgedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "movie_hedged_requests_total",
+			Help: "Number of hedged downstream calls by method and result",
+		}, []string{"method", "result"}),
+		SimilarTruncated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "movie_similar_truncated_total",
+			Help: "Number of similar movie rankings that hit the cap on candidates",
+		}),
 	}
-	reg.MustRegister(m.MovieGetDetailsTotal, m.MovieFilmPopularity, m.MovieGetDetailsDuration)
+	reg.MustRegister(m.MovieGetDetailsTotal, m.MovieBatchGetTotal, m.MovieFilmPopularity, m.MovieGetDetailsDuration, m.MovieDetailsCache, m.CircuitBreakerState, m.HedgedRequests, m.SimilarTruncated)
 
 	return m
 }
@@ -43,6 +91,10 @@ func (m *Metrics) IncMovieGetTotalCount(outcome RequestOutcome) {
 	m.MovieGetDetailsTotal.WithLabelValues(string(outcome)).Inc()
 }
 
+func (m *Metrics) IncMovieBatchGetTotalCount(outcome RequestOutcome) {
+	m.MovieBatchGetTotal.WithLabelValues(string(outcome)).Inc()
+}
+
 func (m *Metrics) IncMoviePopularityCount(filmName string) {
 	m.MovieFilmPopularity.WithLabelValues(filmName).Inc()
 }
@@ -50,3 +102,58 @@ func (m *Metrics) IncMoviePopularityCount(filmName string) {
 func (m *Metrics) ObserveMovieGetDuration(durationSecs float64) {
 	m.MovieGetDetailsDuration.Observe(durationSecs)
 }
+
+func (m *Metrics) IncMovieDetailsCacheCount(result CacheResult) {
+	m.MovieDetailsCache.WithLabelValues(string(result)).Inc()
+}
+
+func (m *Metrics) SetCircuitBreakerState(service string, state int) {
+	m.CircuitBreakerState.WithLabelValues(service).Set(float64(state))
+}
+
+func (m *Metrics) IncHedgeCount(method string, result HedgeResult) {
+	m.HedgedRequests.WithLabelValues(method, string(result)).Inc()
+}
+
+func (m *Metrics) IncSimilarTruncatedCount() {
+	m.SimilarTruncated.Inc()
+}
+
+// ConcurrencyMetrics describe the concurrency limit of a gRPC server.
+type ConcurrencyMetrics struct {
+	ConcurrencyLimit prometheus.Gauge
+	InFlightRequests prometheus.Gauge
+	ShedRequests     *prometheus.CounterVec
+}
+
+func NewConcurrency(reg prometheus.Registerer) *ConcurrencyMetrics {
+	m := &ConcurrencyMetrics{
+		ConcurrencyLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "grpc_server_concurrency_limit",
+			Help: "Current number of calls the server handles at once",
+		}),
+		InFlightRequests: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "grpc_server_in_flight_requests",
+			Help: "Number of calls being handled",
+		}),
+		ShedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_shed_requests_total",
+			Help: "Number of calls rejected by the concurrency limit by method",
+		}, []string{"method"}),
+	}
+	reg.MustRegister(m.ConcurrencyLimit, m.InFlightRequests, m.ShedRequests)
+
+	return m
+}
+
+func (m *ConcurrencyMetrics) SetConcurrencyLimit(limit int) {
+	m.ConcurrencyLimit.Set(float64(limit))
+}
+
+func (m *ConcurrencyMetrics) SetInFlight(n int) {
+	m.InFlightRequests.Set(float64(n))
+}
+
+func (m *ConcurrencyMetrics) IncShedCount(method string) {
+	m.ShedRequests.WithLabelValues(method).Inc()
+}
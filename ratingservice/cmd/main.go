@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -12,21 +13,33 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/ochamekan/ms/gen"
-	"github.com/ochamekan/ms/pkg/consul"
+	"github.com/ochamekan/ms/internal/healthcheck"
+	"github.com/ochamekan/ms/internal/loadshed"
+	"github.com/ochamekan/ms/internal/registry"
 	"github.com/ochamekan/ms/pkg/discovery"
 	"github.com/ochamekan/ms/pkg/logging"
+	"github.com/ochamekan/ms/pkg/metrics"
 	"github.com/ochamekan/ms/ratingservice/internal/controller/rating"
 	grpchandler "github.com/ochamekan/ms/ratingservice/internal/handler/grpc"
 	"github.com/ochamekan/ms/ratingservice/internal/repository/cache"
 	"github.com/ochamekan/ms/ratingservice/internal/repository/postgres"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 const (
 	serviceName = "rating"
 	port        = 8082
+	metricsPort = 9102
+
+	// latencyTarget is the latency above which calls are taken as a sign of
+	// overload. Aggregating ratings takes about a second on a cache miss.
+	latencyTarget = 2 * time.Second
 )
 
 func main() {
@@ -40,29 +53,29 @@ func main() {
 		logger.Fatal("Error loading .env file", zap.Error(err))
 	}
 
-	registry, err := consul.NewRegistry("discovery:8500")
-	if err != nil {
-		logger.Fatal("Failed to create consul registry", zap.Error(err))
-	}
+	reg := prometheus.NewRegistry()
+	concurrencyMetrics := metrics.NewConcurrency(reg)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	go func() {
+		http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", metricsPort), nil); err != nil {
+			logger.Error("Failed to serve metrics", zap.Error(err))
+		}
+	}()
 
-	instanceID := discovery.GenerateInstanceID(serviceName)
+	instance, err := registry.Instance(serviceName, fmt.Sprintf("rating:%d", port))
+	if err != nil {
+		logger.Fatal("Failed to describe instance", zap.Error(err))
+	}
 
-	if err := registry.Register(ctx, instanceID, serviceName, fmt.Sprintf("rating:%d", port)); err != nil {
-		logger.Fatal("Failed to register instance", zap.Error(err))
+	serviceRegistry, closeRegistry, err := registry.New(logger)
+	if err != nil {
+		logger.Fatal("Failed to create service registry", zap.Error(err))
 	}
+	defer closeRegistry()
 
-	go func() {
-		for {
-			if err := registry.ReportHealthyState(instanceID, serviceName); err != nil {
-				logger.Error("Failed to report healthy state", zap.Error(err))
-			}
-			time.Sleep(1 * time.Second)
-		}
-	}()
-	defer registry.Deregister(ctx, instanceID, serviceName)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	repo, closer, err := postgres.New()
 	if err != nil {
@@ -70,10 +83,11 @@ func main() {
 	}
 	defer closer()
 
-	cache, err := cache.New(serviceName)
+	cache, err := cache.New(serviceName, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize redis database", zap.Error(err))
 	}
+	go cache.Run(ctx)
 
 	ctrl := rating.New(repo, cache, logger)
 
@@ -84,16 +98,37 @@ func main() {
 		logger.Fatal("Failed to listen", zap.Error(err))
 	}
 
-	srv := grpc.NewServer()
+	concurrencyConfig := loadshed.DefaultConfig
+	concurrencyConfig.LatencyTarget = latencyTarget
+	srv := grpc.NewServer(grpc.UnaryInterceptor(loadshed.UnaryServerInterceptor(concurrencyConfig, concurrencyMetrics)))
 	reflection.Register(srv)
 
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	go healthcheck.New(healthSrv, gen.RatingService_ServiceDesc.ServiceName, map[string]healthcheck.Check{
+		"postgres": repo.Ping,
+	}, map[string]healthcheck.Check{
+		"redis": cache.Ping,
+	}, logger).Run(ctx)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	// Registered only once the server is ready to accept calls.
+	registration, err := discovery.Register(ctx, serviceRegistry, instance, logger)
+	if err != nil {
+		logger.Fatal("Failed to register instance", zap.Error(err))
+	}
+
 	var wg sync.WaitGroup
 
 	wg.Go(func() {
 		s := <-sigChan
 		logger.Info("Received signal, attempting graceful shutdown", zap.Stringer("signal", s))
+		if err := registration.Deregister(context.Background()); err != nil {
+			logger.Error("Failed to deregister instance", zap.Error(err))
+		}
+		healthSrv.Shutdown()
 		cancel()
 		srv.GracefulStop()
 		logger.Info("Gracefully stopped the gRPC server for rating service")
@@ -101,6 +136,7 @@ func main() {
 
 	gen.RegisterRatingServiceServer(srv, h)
 	if err := srv.Serve(lis); err != nil {
+		registration.Deregister(context.Background())
 		logger.Fatal("Failed to serve", zap.Error(err))
 	}
 
@@ -3,12 +3,28 @@ package rating
 import (
 	"context"
 	"errors"
+	"math"
+	"math/rand/v2"
+	"strconv"
 	"time"
 
 	"github.com/ochamekan/ms/pkg/logging"
 	"github.com/ochamekan/ms/ratingservice/internal/repository"
+	"github.com/ochamekan/ms/ratingservice/internal/repository/cache"
 	"github.com/ochamekan/ms/ratingservice/pkg/model"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// lockTTL bounds how long one instance computes a rating while the
+	// others wait for it.
+	lockTTL = 5 * time.Second
+	// lockPollInterval is how often waiting instances look for the rating.
+	lockPollInterval = 100 * time.Millisecond
+	// earlyRefreshBeta scales how early ratings are recomputed. Above 1
+	// favours earlier recomputation.
+	earlyRefreshBeta = 1.0
 )
 
 var ErrNotFound = errors.New("ratings not found for a record")
@@ -16,32 +32,182 @@ var ErrNotFound = errors.New("ratings not found for a record")
 type ratingRepository interface {
 	Get(ctx context.Context, movieID model.MovieID) ([]model.Rating, error)
 	Put(ctx context.Context, movieID model.MovieID, rating model.RatingValue) error
+	BatchGet(ctx context.Context, movieIDs []model.MovieID) ([]model.Rating, error)
+	List(ctx context.Context, limit, offset int) ([]model.AggregatedRating, error)
 }
 
 type ratingCache interface {
-	GetAggregatedRating(ctx context.Context, movieID model.MovieID) (float64, error)
-	PutAggregatedRating(ctx context.Context, movieID model.MovieID, rating float64) error
+	GetAggregatedRating(ctx context.Context, movieID model.MovieID) (cache.Entry, error)
+	PutAggregatedRating(ctx context.Context, movieID model.MovieID, rating float64, delta time.Duration) error
+	PutMissingRating(ctx context.Context, movieID model.MovieID) error
+	DeleteAggregatedRating(ctx context.Context, movieID model.MovieID) error
+	BatchGetAggregatedRatings(ctx context.Context, movieIDs []model.MovieID) (map[model.MovieID]float64, error)
+	Lock(ctx context.Context, movieID model.MovieID, lockTTL time.Duration) (func(), bool, error)
+	Locked(ctx context.Context, movieID model.MovieID) (bool, error)
 }
 
 type Controller struct {
 	repo   ratingRepository
 	cache  ratingCache
+	group  singleflight.Group
 	logger *zap.Logger
 }
 
 func New(repo ratingRepository, cache ratingCache, logger *zap.Logger) *Controller {
-	return &Controller{repo, cache, logger.With(zap.String(logging.FieldComponent, "rating controller"))}
+	return &Controller{repo: repo, cache: cache, logger: logger.With(zap.String(logging.FieldComponent, "rating controller"))}
 }
 
+// GetAggregatedRating returns the aggregated rating of a movie. It is
+// computed at most once at a time per movie across all instances: callers
+// on one instance share a computation, and instances take a lock in Redis
+// for it. Callers that do not get the lock serve the previous rating if it
+// is cached, or wait for the lock holder's. Ratings are recomputed in the
+// background a little before they expire, more likely the closer to expiry
+// and the longer they take to compute, so that popular movies rarely miss.
 func (c *Controller) GetAggregatedRating(ctx context.Context, movieID model.MovieID) (float64, error) {
+	ch := c.group.DoChan(strconv.Itoa(int(movieID)), func() (any, error) {
+		// The computation is shared, so the first caller going away does
+		// not end it. It ends by that caller's deadline, so that database
+		// calls never outlive the inbound call, and by the time the lock
+		// it may hold expires.
+		fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockTTL)
+		defer cancel()
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancelDeadline context.CancelFunc
+			fctx, cancelDeadline = context.WithDeadline(fctx, deadline)
+			defer cancelDeadline()
+		}
+
+		return c.aggregatedRating(fctx, movieID)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return 0, res.Err
+		}
+		return res.Val.(float64), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (c *Controller) aggregatedRating(ctx context.Context, movieID model.MovieID) (float64, error) {
 	logger := c.logger.With(zap.String(logging.FieldEndpoint, "GetAggregatedRating"))
-	cachedRes, err := c.cache.GetAggregatedRating(ctx, movieID)
-	if err == nil {
-		return cachedRes, nil
+
+	cached, err := c.cache.GetAggregatedRating(ctx, movieID)
+	hit := err == nil
+	if now := time.Now(); hit && !cached.Expired(now) {
+		if cached.Missing {
+			return 0, ErrNotFound
+		}
+		if refreshEarly(cached, now) {
+			c.refresh(ctx, movieID, cached)
+		}
+		return cached.Rating, nil
 	}
 
+	unlock, locked, err := c.cache.Lock(ctx, movieID, lockTTL)
+	switch {
+	case err != nil:
+		logger.Warn("Failed to lock rating computation, computing without lock", zap.Error(err))
+	case locked:
+		defer unlock()
+
+		// The previous holder may have cached the rating between the read
+		// above and taking the lock.
+		if e, err := c.cache.GetAggregatedRating(ctx, movieID); err == nil && !e.Expired(time.Now()) {
+			if e.Missing {
+				return 0, ErrNotFound
+			}
+			return e.Rating, nil
+		}
+	case hit && !cached.Missing:
+		// Another instance is recomputing it.
+		return cached.Rating, nil
+	default:
+		if e, ok := c.waitForRating(ctx, movieID); ok {
+			if e.Missing {
+				return 0, ErrNotFound
+			}
+			return e.Rating, nil
+		}
+		logger.Warn("Rating computation ended without a rating, computing without lock")
+	}
+
+	return c.computeAggregatedRating(ctx, movieID)
+}
+
+// refresh recomputes a rating in the background, unless this or another
+// instance already is or has since cached.
+func (c *Controller) refresh(ctx context.Context, movieID model.MovieID, cached cache.Entry) {
+	// The rating is recomputed for later callers, not this one, which is
+	// served the cached rating right away.
+	ctx = context.WithoutCancel(ctx)
+
+	c.group.DoChan("refresh:"+strconv.Itoa(int(movieID)), func() (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, lockTTL)
+		defer cancel()
+
+		unlock, locked, err := c.cache.Lock(ctx, movieID, lockTTL)
+		if err != nil || !locked {
+			return nil, err
+		}
+		defer unlock()
+
+		if e, err := c.cache.GetAggregatedRating(ctx, movieID); err == nil && e.ExpiresAt.After(cached.ExpiresAt) {
+			return e.Rating, nil
+		}
+		return c.computeAggregatedRating(ctx, movieID)
+	})
+}
+
+// refreshEarly decides whether to recompute a cached rating before it
+// expires. The chance grows as expiry nears, and sooner for ratings that
+// take longer to compute (XFetch).
+func refreshEarly(e cache.Entry, now time.Time) bool {
+	early := time.Duration(-float64(e.Delta) * earlyRefreshBeta * math.Log(rand.Float64()))
+	return e.Expired(now.Add(early))
+}
+
+// waitForRating polls the cache for the rating computed by the holder of
+// the lock. It gives up as soon as the lock is released or expires without
+// a rating being cached.
+func (c *Controller) waitForRating(ctx context.Context, movieID model.MovieID) (cache.Entry, bool) {
+	ctx, cancel := context.WithTimeout(ctx, lockTTL)
+	defer cancel()
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return cache.Entry{}, false
+		case <-ticker.C:
+		}
+
+		// The holder caches the rating before releasing the lock, so the
+		// lock is looked at first.
+		locked, lockErr := c.cache.Locked(ctx, movieID)
+		if e, err := c.cache.GetAggregatedRating(ctx, movieID); err == nil && !e.Expired(time.Now()) {
+			return e, true
+		}
+		if lockErr == nil && !locked {
+			return cache.Entry{}, false
+		}
+	}
+}
+
+func (c *Controller) computeAggregatedRating(ctx context.Context, movieID model.MovieID) (float64, error) {
+	logger := c.logger.With(zap.String(logging.FieldEndpoint, "GetAggregatedRating"))
+	start := time.Now()
+
 	ratings, err := c.repo.Get(ctx, movieID)
 	if err != nil && err == repository.ErrNotFound {
+		if err := c.cache.PutMissingRating(ctx, movieID); err != nil {
+			logger.Error("Failed to update redis cache", zap.Error(err))
+		}
 		return 0, ErrNotFound
 	} else if err != nil {
 		return 0, err
@@ -61,13 +227,99 @@ func (c *Controller) GetAggregatedRating(ctx context.Context, movieID model.Movi
 
 	res := sum / float64(len(ratings))
 
-	if err := c.cache.PutAggregatedRating(ctx, movieID, res); err != nil {
+	if err := c.cache.PutAggregatedRating(ctx, movieID, res, time.Since(start)); err != nil {
 		logger.Error("Failed to update redis cache", zap.Error(err))
 	}
 
 	return res, nil
 }
 
+// PutRating adds a rating of a movie, and evicts its cached aggregated
+// rating on every instance.
 func (c *Controller) PutRating(ctx context.Context, movieID model.MovieID, rating model.RatingValue) error {
-	return c.repo.Put(ctx, movieID, rating)
+	if err := c.repo.Put(ctx, movieID, rating); err != nil {
+		return err
+	}
+
+	if err := c.cache.DeleteAggregatedRating(ctx, movieID); err != nil {
+		c.logger.Error("Failed to invalidate redis cache", zap.String(logging.FieldEndpoint, "PutRating"), zap.Error(err))
+	}
+	return nil
+}
+
+// BatchGetAggregatedRatings returns aggregated ratings for the given movies,
+// computing the uncached ones in a single pass. Movies without ratings are
+// omitted from the result.
+func (c *Controller) BatchGetAggregatedRatings(ctx context.Context, movieIDs []model.MovieID) (map[model.MovieID]float64, error) {
+	logger := c.logger.With(zap.String(logging.FieldEndpoint, "BatchGetAggregatedRatings"))
+	res, err := c.cache.BatchGetAggregatedRatings(ctx, movieIDs)
+	if err != nil {
+		logger.Warn("Failed to read redis cache", zap.Error(err))
+		res = make(map[model.MovieID]float64, len(movieIDs))
+	}
+
+	var missing []model.MovieID
+	for _, id := range movieIDs {
+		if _, ok := res[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	start := time.Now()
+	ratings, err := c.repo.BatchGet(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(ratings) == 0 {
+		return res, nil
+	}
+
+	sums := make(map[model.MovieID]float64)
+	counts := make(map[model.MovieID]int)
+	for _, r := range ratings {
+		sums[r.MovieID] += float64(r.Rating)
+		counts[r.MovieID]++
+	}
+
+	// Emulating hard work
+	time.Sleep(1 * time.Second)
+
+	for id, sum := range sums {
+		res[id] = sum / float64(counts[id])
+		if err := c.cache.PutAggregatedRating(ctx, id, res[id], time.Since(start)); err != nil {
+			logger.Error("Failed to update redis cache", zap.Error(err))
+		}
+	}
+
+	return res, nil
+}
+
+// BatchGetRatingDistributions returns the number of ratings per rating value
+// for the given movies. Movies without ratings are omitted from the result.
+func (c *Controller) BatchGetRatingDistributions(ctx context.Context, movieIDs []model.MovieID) (map[model.MovieID]model.Distribution, error) {
+	ratings, err := c.repo.BatchGet(ctx, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[model.MovieID]model.Distribution)
+	for _, r := range ratings {
+		d, ok := res[r.MovieID]
+		if !ok {
+			d = make(model.Distribution)
+			res[r.MovieID] = d
+		}
+		d[r.Rating]++
+	}
+
+	return res, nil
+}
+
+// ListAggregatedRatings returns a page of rated movies ordered by aggregated
+// rating, highest first.
+func (c *Controller) ListAggregatedRatings(ctx context.Context, limit, offset int) ([]model.AggregatedRating, error) {
+	return c.repo.List(ctx, limit, offset)
 }
@@ -0,0 +1,165 @@
+package rating
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ochamekan/ms/ratingservice/internal/repository"
+	"github.com/ochamekan/ms/ratingservice/internal/repository/cache"
+	"github.com/ochamekan/ms/ratingservice/pkg/model"
+	"go.uber.org/zap"
+)
+
+// stubRepository holds the ratings of movies, and counts reads and records
+// the deadline of the last one.
+type stubRepository struct {
+	ratings  map[model.MovieID][]model.Rating
+	gets     int
+	deadline time.Time
+}
+
+func (r *stubRepository) Get(ctx context.Context, movieID model.MovieID) ([]model.Rating, error) {
+	r.gets++
+	r.deadline, _ = ctx.Deadline()
+	if len(r.ratings[movieID]) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return r.ratings[movieID], nil
+}
+
+func (r *stubRepository) Put(_ context.Context, movieID model.MovieID, rating model.RatingValue) error {
+	r.ratings[movieID] = append(r.ratings[movieID], model.Rating{MovieID: movieID, Rating: rating})
+	return nil
+}
+
+func (r *stubRepository) BatchGet(context.Context, []model.MovieID) ([]model.Rating, error) {
+	return nil, nil
+}
+
+func (r *stubRepository) List(context.Context, int, int) ([]model.AggregatedRating, error) {
+	return nil, nil
+}
+
+func newController(t *testing.T) (*Controller, *stubRepository, *cache.Cache) {
+	t.Helper()
+
+	m := miniredis.RunT(t)
+	t.Setenv("REDIS_ADDR", m.Addr())
+	c, err := cache.New("rating", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &stubRepository{ratings: make(map[model.MovieID][]model.Rating)}
+	return New(repo, c, zap.NewNop()), repo, c
+}
+
+func TestGetAggregatedRatingCachesMissingRatings(t *testing.T) {
+	ctx := context.Background()
+	ctrl, repo, _ := newController(t)
+
+	for range 2 {
+		if _, err := ctrl.GetAggregatedRating(ctx, 1); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v, want %v", err, ErrNotFound)
+		}
+	}
+	if repo.gets != 1 {
+		t.Errorf("got %d reads of the ratings, want 1", repo.gets)
+	}
+}
+
+func TestWaitForRatingStopsWhenLockIsReleased(t *testing.T) {
+	ctx := context.Background()
+	ctrl, _, c := newController(t)
+
+	unlock, locked, err := c.Lock(ctx, 1, lockTTL)
+	if err != nil || !locked {
+		t.Fatalf("got %v, %v", locked, err)
+	}
+	time.AfterFunc(2*lockPollInterval, unlock)
+
+	start := time.Now()
+	if _, ok := ctrl.waitForRating(ctx, 1); ok {
+		t.Error("got a rating, want none")
+	}
+	if elapsed := time.Since(start); elapsed > lockTTL/2 {
+		t.Errorf("waited %v after the lock was released", elapsed)
+	}
+}
+
+func TestWaitForRatingReturnsHoldersRating(t *testing.T) {
+	ctx := context.Background()
+	ctrl, _, c := newController(t)
+
+	unlock, locked, err := c.Lock(ctx, 1, lockTTL)
+	if err != nil || !locked {
+		t.Fatalf("got %v, %v", locked, err)
+	}
+	time.AfterFunc(2*lockPollInterval, func() {
+		c.PutAggregatedRating(ctx, 1, 4, time.Second)
+		unlock()
+	})
+
+	e, ok := ctrl.waitForRating(ctx, 1)
+	if !ok || e.Rating != 4 {
+		t.Errorf("got %v, %v, want 4, true", e.Rating, ok)
+	}
+}
+
+func TestPutRatingEvictsCachedRating(t *testing.T) {
+	ctx := context.Background()
+	ctrl, _, c := newController(t)
+
+	if _, err := ctrl.GetAggregatedRating(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want %v", err, ErrNotFound)
+	}
+	if err := ctrl.PutRating(ctx, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetAggregatedRating(ctx, 1); err == nil {
+		t.Error("rating still cached after a new rating")
+	}
+}
+
+func TestGetAggregatedRatingKeepsCallerDeadline(t *testing.T) {
+	ctrl, repo, _ := newController(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if _, err := ctrl.GetAggregatedRating(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want %v", err, ErrNotFound)
+	}
+	if want, _ := ctx.Deadline(); !repo.deadline.Equal(want) {
+		t.Errorf("got database deadline %v, want the caller's %v", repo.deadline, want)
+	}
+}
+
+// racingCache caches a rating right before each lock is taken, as a
+// previous holder of the lock would.
+type racingCache struct {
+	*cache.Cache
+}
+
+func (c racingCache) Lock(ctx context.Context, movieID model.MovieID, lockTTL time.Duration) (func(), bool, error) {
+	if err := c.PutAggregatedRating(ctx, movieID, 3, time.Second); err != nil {
+		return nil, false, err
+	}
+	return c.Cache.Lock(ctx, movieID, lockTTL)
+}
+
+func TestGetAggregatedRatingRereadsCacheAfterLocking(t *testing.T) {
+	_, repo, c := newController(t)
+	ctrl := New(repo, racingCache{c}, zap.NewNop())
+
+	rating, err := ctrl.GetAggregatedRating(context.Background(), 1)
+	if err != nil || rating != 3 {
+		t.Fatalf("got %v, %v, want 3, nil", rating, err)
+	}
+	if repo.gets != 0 {
+		t.Errorf("got %d reads of the ratings, want the cached rating", repo.gets)
+	}
+}
@@ -13,6 +13,9 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// maxBatchSize caps the number of records a single batch or list call may return.
+const maxBatchSize = 100
+
 type Handler struct {
 	gen.UnimplementedRatingServiceServer
 	ctrl   *rating.Controller
@@ -60,3 +63,102 @@ func (h *Handler) PutRating(ctx context.Context, req *gen.PutRatingRequest) (*ge
 	logger.Info("Rating successfully added")
 	return &gen.PutRatingResponse{}, nil
 }
+
+func (h *Handler) BatchGetAggregatedRatings(ctx context.Context, req *gen.BatchGetAggregatedRatingsRequest) (*gen.BatchGetAggregatedRatingsResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "BatchGetAggregatedRatings"))
+	if req == nil || len(req.MovieIds) == 0 || len(req.MovieIds) > maxBatchSize {
+		logger.Warn("nil request or incorrect number of movie ids")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or number of movie ids not in [1, %d]", maxBatchSize)
+	}
+
+	ids := make([]model.MovieID, len(req.MovieIds))
+	for i, id := range req.MovieIds {
+		if id <= 0 {
+			logger.Warn("incorrect movie id", zap.Int32("movie_id", id))
+			return nil, status.Errorf(codes.InvalidArgument, "incorrect movie id %d", id)
+		}
+		ids[i] = model.MovieID(id)
+	}
+
+	logger.Info("Getting aggregated ratings")
+	ratings, err := h.ctrl.BatchGetAggregatedRatings(ctx, ids)
+	if err != nil {
+		logger.Error("Failed to get ratings", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &gen.BatchGetAggregatedRatingsResponse{}
+	for _, id := range ids {
+		if v, ok := ratings[id]; ok {
+			res.Ratings = append(res.Ratings, &gen.AggregatedRating{MovieId: int32(id), Rating: v})
+			delete(ratings, id)
+		}
+	}
+
+	logger.Info("Ratings successfully retrieved")
+	return res, nil
+}
+
+func (h *Handler) ListAggregatedRatings(ctx context.Context, req *gen.ListAggregatedRatingsRequest) (*gen.ListAggregatedRatingsResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "ListAggregatedRatings"))
+	if req == nil || req.Limit <= 0 || req.Limit > maxBatchSize || req.Offset < 0 {
+		logger.Warn("nil request or incorrect limit or offset")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or limit not in [1, %d] or negative offset", maxBatchSize)
+	}
+
+	logger.Info("Listing aggregated ratings")
+	ratings, err := h.ctrl.ListAggregatedRatings(ctx, int(req.Limit), int(req.Offset))
+	if err != nil {
+		logger.Error("Failed to list ratings", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &gen.ListAggregatedRatingsResponse{Ratings: make([]*gen.AggregatedRating, len(ratings))}
+	for i, r := range ratings {
+		res.Ratings[i] = &gen.AggregatedRating{MovieId: int32(r.MovieID), Rating: r.Rating}
+	}
+
+	logger.Info("Ratings successfully listed")
+	return res, nil
+}
+
+func (h *Handler) BatchGetRatingDistributions(ctx context.Context, req *gen.BatchGetRatingDistributionsRequest) (*gen.BatchGetRatingDistributionsResponse, error) {
+	logger := h.logger.With(zap.String(logging.FieldEndpoint, "BatchGetRatingDistributions"))
+	if req == nil || len(req.MovieIds) == 0 || len(req.MovieIds) > maxBatchSize {
+		logger.Warn("nil request or incorrect number of movie ids")
+		return nil, status.Errorf(codes.InvalidArgument, "nil req or number of movie ids not in [1, %d]", maxBatchSize)
+	}
+
+	ids := make([]model.MovieID, len(req.MovieIds))
+	for i, id := range req.MovieIds {
+		if id <= 0 {
+			logger.Warn("incorrect movie id", zap.Int32("movie_id", id))
+			return nil, status.Errorf(codes.InvalidArgument, "incorrect movie id %d", id)
+		}
+		ids[i] = model.MovieID(id)
+	}
+
+	logger.Info("Getting rating distributions")
+	distributions, err := h.ctrl.BatchGetRatingDistributions(ctx, ids)
+	if err != nil {
+		logger.Error("Failed to get rating distributions", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &gen.BatchGetRatingDistributionsResponse{}
+	for _, id := range ids {
+		d, ok := distributions[id]
+		if !ok {
+			continue
+		}
+		counts := make(map[int32]int64, len(d))
+		for v, n := range d {
+			counts[int32(v)] = n
+		}
+		res.Distributions = append(res.Distributions, &gen.RatingDistribution{MovieId: int32(id), Counts: counts})
+		delete(distributions, id)
+	}
+
+	logger.Info("Rating distributions successfully retrieved")
+	return res, nil
+}
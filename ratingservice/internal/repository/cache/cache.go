@@ -2,20 +2,78 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/ochamekan/ms/internal/localcache"
+	"github.com/ochamekan/ms/pkg/logging"
 	"github.com/ochamekan/ms/ratingservice/pkg/model"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 )
 
+// schemaVersion is part of every key. It is 2 since entries record movies
+// without ratings, which older instances would read as a rating of 0.
+const schemaVersion = 2
+
+const (
+	// ttl is how long an aggregated rating is fresh.
+	ttl = 1 * time.Minute
+	// grace is how long an expired rating is kept to serve while it is
+	// being recomputed.
+	grace = 30 * time.Second
+	// missingTTL is how long a movie is known to have no ratings, so that
+	// callers waiting for its rating are not left polling.
+	missingTTL = 5 * time.Second
+
+	// Ratings are recomputed every minute, so in-process copies are kept
+	// for seconds in case an instance misses the eviction.
+	localSize = 10000
+	localTTL  = 10 * time.Second
+)
+
+// unlock deletes a lock only if it is still held with the given token, so
+// that a lock that expired and was taken by another instance is kept.
+var unlock = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Entry is a cached aggregated rating.
+type Entry struct {
+	Rating float64 `json:"rating"`
+	// ExpiresAt is when the rating stops being fresh. The entry is kept
+	// for a grace period after it.
+	ExpiresAt time.Time `json:"expires_at"`
+	// Delta is how long computing the rating took.
+	Delta time.Duration `json:"delta"`
+	// Missing is set when the movie has no ratings.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Expired reports whether the rating is no longer fresh at now.
+func (e Entry) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// Cache keeps aggregated ratings in Redis, and copies of them in process.
 type Cache struct {
 	client *redis.Client
-	name   string
+	local  *localcache.Cache[Entry]
+	prefix string
+	logger *zap.Logger
 }
 
-func New(name string) (*Cache, error) {
+// New creates a cache of ratings under keys prefixed with name. Run must be
+// running for in-process copies to be evicted when other instances
+// recompute ratings.
+func New(name string, logger *zap.Logger) (*Cache, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     os.Getenv("REDIS_ADDR"),
 		Password: os.Getenv("REDIS_PASSWORD"),
@@ -27,17 +85,171 @@ func New(name string) (*Cache, error) {
 		return nil, err
 	}
 
-	return &Cache{client, name}, nil
+	prefix := fmt.Sprintf("%s:v%d", name, schemaVersion)
+	return &Cache{
+		client: client,
+		local:  localcache.New[Entry](client, prefix+":invalidate", localSize, localTTL, logger),
+		prefix: prefix,
+		logger: logger.With(zap.String(logging.FieldComponent, "rating cache")),
+	}, nil
+}
+
+// Run evicts in-process copies of ratings recomputed by other instances
+// until ctx is done.
+func (c *Cache) Run(ctx context.Context) {
+	c.local.Run(ctx)
+}
+
+// Ping checks that Redis is reachable.
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// GetAggregatedRating returns the cached rating of a movie, which may have
+// expired within the grace period. Expired in-process copies are looked up
+// in Redis, where another instance may have cached a newer rating.
+func (c *Cache) GetAggregatedRating(ctx context.Context, movieID model.MovieID) (Entry, error) {
+	key := c.key(movieID)
+	if e, ok := c.local.Get(key); ok && !e.Expired(time.Now()) {
+		return e, nil
+	}
+
+	at := time.Now()
+	val, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal(val, &e); err != nil {
+		return Entry{}, err
+	}
+	c.local.Add(key, e, at)
+	return e, nil
+}
+
+// PutAggregatedRating caches the rating of a movie that took delta to
+// compute, and evicts the copies of the previous one on other instances.
+func (c *Cache) PutAggregatedRating(ctx context.Context, movieID model.MovieID, rating float64, delta time.Duration) error {
+	return c.put(ctx, movieID, Entry{Rating: rating, ExpiresAt: time.Now().Add(ttl), Delta: delta}, ttl+grace)
+}
+
+// PutMissingRating caches briefly that a movie has no ratings.
+func (c *Cache) PutMissingRating(ctx context.Context, movieID model.MovieID) error {
+	return c.put(ctx, movieID, Entry{ExpiresAt: time.Now().Add(missingTTL), Missing: true}, missingTTL)
+}
+
+func (c *Cache) put(ctx context.Context, movieID model.MovieID, e Entry, expiration time.Duration) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	key := c.key(movieID)
+	if err := c.client.Set(ctx, key, b, expiration).Err(); err != nil {
+		return err
+	}
+	// Other instances serve their copies for at most localTTL if they
+	// are not told, which is no reason to fail the write.
+	if err := c.local.Invalidate(ctx, key); err != nil {
+		c.logger.Warn("Failed to invalidate in-process copies", zap.String("key", key), zap.Error(err))
+	}
+	c.local.Add(key, e, time.Now())
+
+	return nil
+}
+
+// DeleteAggregatedRating evicts the cached rating of a movie, including the
+// copies on every instance.
+func (c *Cache) DeleteAggregatedRating(ctx context.Context, movieID model.MovieID) error {
+	key := c.key(movieID)
+	if err := c.client.Del(ctx, key).Err(); err != nil {
+		return err
+	}
+	return c.local.Invalidate(ctx, key)
 }
 
-func (c *Cache) GetAggregatedRating(ctx context.Context, movieID model.MovieID) (float64, error) {
-	val, err := c.client.Get(ctx, fmt.Sprintf("%s:%v", c.name, movieID)).Float64()
+// BatchGetAggregatedRatings returns cached ratings for the given movies,
+// skipping the ones that are not cached, have expired or have no ratings.
+func (c *Cache) BatchGetAggregatedRatings(ctx context.Context, movieIDs []model.MovieID) (map[model.MovieID]float64, error) {
+	res := make(map[model.MovieID]float64, len(movieIDs))
+	if len(movieIDs) == 0 {
+		return res, nil
+	}
+
+	now := time.Now()
+	var (
+		keys    []string
+		missing []model.MovieID
+	)
+	for _, id := range movieIDs {
+		key := c.key(id)
+		if e, ok := c.local.Get(key); ok && !e.Expired(now) && !e.Missing {
+			res[id] = e.Rating
+			continue
+		}
+		keys = append(keys, key)
+		missing = append(missing, id)
+	}
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	at := time.Now()
+	vals, err := c.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(s), &e); err != nil {
+			return nil, err
+		}
+		c.local.Add(keys[i], e, at)
+		if !e.Expired(now) && !e.Missing {
+			res[missing[i]] = e.Rating
+		}
 	}
-	return val, nil
+
+	return res, nil
+}
+
+// Lock takes the lock on computing the rating of a movie for at most
+// lockTTL. It returns false if another caller holds it, otherwise a
+// function releasing it.
+func (c *Cache) Lock(ctx context.Context, movieID model.MovieID, lockTTL time.Duration) (func(), bool, error) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	key := c.lockKey(movieID)
+	ok, err := c.client.SetNX(ctx, key, token, lockTTL).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	return func() {
+		// The lock is released even if the caller has gone away.
+		unlock.Run(context.WithoutCancel(ctx), c.client, []string{key}, token)
+	}, true, nil
+}
+
+// Locked reports whether the lock on computing the rating of a movie is
+// held.
+func (c *Cache) Locked(ctx context.Context, movieID model.MovieID) (bool, error) {
+	n, err := c.client.Exists(ctx, c.lockKey(movieID)).Result()
+	return n > 0, err
+}
+
+func (c *Cache) lockKey(movieID model.MovieID) string {
+	return fmt.Sprintf("%s:lock:%d", c.prefix, movieID)
 }
 
-func (c *Cache) PutAggregatedRating(ctx context.Context, movieID model.MovieID, rating float64) error {
-	return c.client.Set(ctx, fmt.Sprintf("%s:%v", c.name, movieID), rating, 1*time.Minute).Err()
+func (c *Cache) key(movieID model.MovieID) string {
+	return fmt.Sprintf("%s:%d", c.prefix, movieID)
 }
@@ -26,6 +26,11 @@ func New() (*Repository, func(), error) {
 	return &Repository{dbpool}, closer, nil
 }
 
+// Ping checks that the database is reachable.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx)
+}
+
 func (r *Repository) Get(ctx context.Context, movieID model.MovieID) ([]model.Rating, error) {
 	rows, err := r.db.Query(ctx, "SELECT * FROM ratings WHERE movie_id = $1", movieID)
 	if err != nil {
@@ -54,3 +59,43 @@ func (r *Repository) Put(ctx context.Context, movieID model.MovieID, rating mode
 	_, err := r.db.Exec(ctx, "INSERT INTO ratings (rating, movie_id) VALUES ($1, $2)", rating, movieID)
 	return err
 }
+
+func (r *Repository) BatchGet(ctx context.Context, movieIDs []model.MovieID) ([]model.Rating, error) {
+	rows, err := r.db.Query(ctx, "SELECT id, movie_id, rating FROM ratings WHERE movie_id = ANY($1)", movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []model.Rating
+
+	for rows.Next() {
+		var r model.Rating
+		if err := rows.Scan(&r.ID, &r.MovieID, &r.Rating); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, r)
+	}
+
+	return ratings, rows.Err()
+}
+
+func (r *Repository) List(ctx context.Context, limit, offset int) ([]model.AggregatedRating, error) {
+	rows, err := r.db.Query(ctx, "SELECT movie_id, AVG(rating)::float8 AS avg FROM ratings GROUP BY movie_id ORDER BY avg DESC, movie_id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.AggregatedRating
+
+	for rows.Next() {
+		var a model.AggregatedRating
+		if err := rows.Scan(&a.MovieID, &a.Rating); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+
+	return res, rows.Err()
+}
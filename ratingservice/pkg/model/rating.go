@@ -10,3 +10,11 @@ type Rating struct {
 	MovieID MovieID     `json:"movie_id"`
 	Rating  RatingValue `json:"rating"`
 }
+
+// Distribution is the number of ratings per rating value.
+type Distribution map[RatingValue]int64
+
+type AggregatedRating struct {
+	MovieID MovieID `json:"movie_id"`
+	Rating  float64 `json:"rating"`
+}